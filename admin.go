@@ -0,0 +1,160 @@
+package fauxmux
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// endpointPatch changes the latency and frequencies of an endpoint through the admin API
+type endpointPatch struct {
+	MinLatency     *time.Duration `yaml:"min_latency"`
+	MaxLatency     *time.Duration `yaml:"max_latency"`
+	Latency        *latencySpec   `yaml:"latency"`
+	ErrorFrequency *float64       `yaml:"error_frequency"`
+	FaultFrequency *float64       `yaml:"fault_frequency"`
+}
+
+// apply changes cfg as described by the patch. A latency distribution replaces min and max latency
+// and vice versa.
+func (p endpointPatch) apply(cfg *EndpointConfig) error {
+	if p.Latency != nil {
+		model, err := p.Latency.model()
+		if err != nil {
+			return fieldError("Latency", err)
+		}
+		cfg.Latency = model
+		cfg.MinLatency, cfg.MaxLatency = 0, 0
+	}
+
+	if p.MinLatency != nil || p.MaxLatency != nil {
+		cfg.Latency = nil
+		if p.MinLatency != nil {
+			cfg.MinLatency = *p.MinLatency
+		}
+		if p.MaxLatency != nil {
+			cfg.MaxLatency = *p.MaxLatency
+		}
+	}
+
+	if p.ErrorFrequency != nil {
+		if cfg.ErrorResponseConfig == nil {
+			return fieldError("ErrorFrequency", fmt.Errorf("endpoint has no error responses"))
+		}
+		errorCfg := *cfg.ErrorResponseConfig
+		errorCfg.Frequency = *p.ErrorFrequency
+		cfg.ErrorResponseConfig = &errorCfg
+	}
+
+	if p.FaultFrequency != nil {
+		if cfg.FaultConfig == nil {
+			return fieldError("FaultFrequency", fmt.Errorf("endpoint has no faults"))
+		}
+		faultCfg := *cfg.FaultConfig
+		faultCfg.Frequency = *p.FaultFrequency
+		cfg.FaultConfig = &faultCfg
+	}
+
+	return nil
+}
+
+// AdminHandler returns the admin API of the Mux, which reconfigures it while it serves requests:
+//
+//	GET    /routes                             lists the registered endpoints
+//	POST   /endpoints                          registers the endpoint described by the body, in the
+//	                                           configuration file format, replacing any existing one
+//	PATCH  /endpoints?method=GET&path=/users   changes min_latency, max_latency, latency,
+//	                                           error_frequency or fault_frequency of an endpoint
+//	DELETE /endpoints?method=GET&path=/users   removes an endpoint
+//	POST   /reset                              resets the Mux, see Reset
+//	GET    /journal                            returns the recorded requests
+//
+// It can be served on its own port or mounted under a prefix of the Mux with WithAdmin.
+func (fm *Mux) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /routes", fm.adminRoutes)
+	mux.HandleFunc("POST /endpoints", fm.adminRegister)
+	mux.HandleFunc("PATCH /endpoints", fm.adminUpdate)
+	mux.HandleFunc("DELETE /endpoints", fm.adminUnregister)
+	mux.HandleFunc("POST /reset", fm.adminReset)
+	mux.HandleFunc("GET /journal", fm.adminJournal)
+	return mux
+}
+
+func (fm *Mux) adminRoutes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, fm.Routes())
+}
+
+func (fm *Mux) adminRegister(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Bad Request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	endpointCfg, err := parseEndpoint(fm, body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Bad Request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := RegisterEndpoint[any](fm, endpointCfg); err != nil {
+		http.Error(w, fmt.Sprintf("Bad Request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	writeJSONStatus(w, http.StatusCreated, (&endpoint{cfg: endpointCfg}).route())
+}
+
+func (fm *Mux) adminUpdate(w http.ResponseWriter, r *http.Request) {
+	method, path := r.URL.Query().Get("method"), r.URL.Query().Get("path")
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Bad Request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var patch endpointPatch
+	if err := decodeDocument(body, &patch, map[string]int{}); err != nil {
+		http.Error(w, fmt.Sprintf("Bad Request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	err = fm.updateEndpoints(method, path, patch.apply)
+	if errors.Is(err, ErrEndpointNotFound) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Bad Request: %v", configErrorAt("", nil, "", err)), http.StatusBadRequest)
+		return
+	}
+
+	routes := make([]Route, 0)
+	for _, route := range fm.Routes() {
+		if route.Method == method && route.Path == path {
+			routes = append(routes, route)
+		}
+	}
+	writeJSON(w, routes)
+}
+
+func (fm *Mux) adminUnregister(w http.ResponseWriter, r *http.Request) {
+	if err := fm.UnregisterEndpoint(r.URL.Query().Get("method"), r.URL.Query().Get("path")); err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (fm *Mux) adminReset(w http.ResponseWriter, r *http.Request) {
+	fm.Reset()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (fm *Mux) adminJournal(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, fm.journal.Requests())
+}
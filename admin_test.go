@@ -0,0 +1,192 @@
+package fauxmux
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// adminRequest sends a request to the admin API of mux mounted under /__admin
+func adminRequest(t *testing.T, mux *Mux, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest(method, "/__admin"+target, r))
+	return w
+}
+
+func TestAdmin_Endpoints(t *testing.T) {
+	mux := NewMux(WithAdmin("/__admin"))
+
+	w := adminRequest(t, mux, "POST", "/endpoints", `{"method": "GET", "path": "/status", "response_format": "json", "response": {"status": "ok"},
+		"errors": {"frequency": 0, "responses": [{"status_code": 503, "response_format": "text", "response": "down"}]}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status code %d but got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+		return w
+	}
+	if w := serve(); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "ok") {
+		t.Fatalf("expected the registered endpoint to respond but got %d %q", w.Code, w.Body.String())
+	}
+
+	w = adminRequest(t, mux, "PATCH", "/endpoints?method=GET&path=/status", `{"error_frequency": 1, "max_latency": "1ms"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code %d but got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var routes []Route
+	if err := json.Unmarshal(w.Body.Bytes(), &routes); err != nil {
+		t.Fatalf("failed to unmarshal routes: %v", err)
+	}
+	if len(routes) != 1 || routes[0].ErrorFrequency != 1 || routes[0].Latency != "uniform(min=0s, max=1ms)" {
+		t.Fatalf("expected the updated route but got %+v", routes)
+	}
+	if w := serve(); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected status code %d but got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	w = adminRequest(t, mux, "PATCH", "/endpoints?method=GET&path=/status", `{"fault_frequency": 1}`)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "fault_frequency") {
+		t.Errorf("expected a bad request naming fault_frequency but got %d %q", w.Code, w.Body.String())
+	}
+
+	if w := adminRequest(t, mux, "DELETE", "/endpoints?method=GET&path=/status", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected status code %d but got %d", http.StatusNoContent, w.Code)
+	}
+	if w := serve(); w.Code != http.StatusNotFound {
+		t.Errorf("expected the removed endpoint to be gone but got status code %d", w.Code)
+	}
+	if w := adminRequest(t, mux, "DELETE", "/endpoints?method=GET&path=/status", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected status code %d but got %d", http.StatusNotFound, w.Code)
+	}
+
+	// the path can be registered again after its endpoints were removed
+	w = adminRequest(t, mux, "POST", "/endpoints", "method: GET\npath: /status\nresponse_format: text\nresponse: back\n")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status code %d but got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	if w := serve(); w.Body.String() != "back" {
+		t.Errorf("expected the endpoint registered again to respond but got %q", w.Body.String())
+	}
+}
+
+func TestAdmin_InvalidEndpoint(t *testing.T) {
+	mux := NewMux(WithAdmin("/__admin"))
+
+	w := adminRequest(t, mux, "POST", "/endpoints", "method: GET\npath: /status\nresponse_format: json\nresponse: {}\nmin_latency: 2s\nmax_latency: 1s\n")
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "6: max_latency") {
+		t.Errorf("expected a bad request at max_latency but got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestAdmin_RoutesResetAndJournal(t *testing.T) {
+	mux := NewMux()
+	admin := httptest.NewServer(mux.AdminHandler())
+	defer admin.Close()
+
+	err := RegisterEndpoint[string](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/users",
+		ResponseFormat: JSON,
+		Latency:        FixedLatency(time.Millisecond),
+		Script:         &ScriptConfig{Steps: []ScriptStep{{}}},
+		FakeDataFunc: func(v interface{}) error {
+			*v.(*string) = "alice"
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	resp, err := http.Get(admin.URL + "/routes")
+	if err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	var routes []Route
+	json.NewDecoder(resp.Body).Decode(&routes)
+	resp.Body.Close()
+	want := Route{Method: "GET", Path: "/users", Latency: "fixed(1ms)", ResponseFormats: []ResponseFormat{JSON}, Scripted: true}
+	if len(routes) != 1 || routes[0].Method != want.Method || routes[0].Path != want.Path || routes[0].Latency != want.Latency || !routes[0].Scripted {
+		t.Fatalf("expected routes [%+v] but got %+v", want, routes)
+	}
+
+	mux.Mux().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users", nil))
+
+	resp, err = http.Get(admin.URL + "/journal")
+	if err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	var requests []RecordedRequest
+	json.Unmarshal(body, &requests)
+	if len(requests) != 1 || requests[0].Path != "/users" {
+		t.Fatalf("expected the journal to hold the request but got %+v", requests)
+	}
+	// the journal uses the same field names as the routes
+	if !strings.Contains(string(body), `"status_code":200`) || !strings.Contains(string(body), `"outcome":"response"`) {
+		t.Errorf("expected snake case field names but got %s", body)
+	}
+
+	resp, err = http.Post(admin.URL+"/reset", "", nil)
+	if err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent || len(mux.Journal().Requests()) != 0 {
+		t.Errorf("expected the journal to be reset but got status code %d and %d requests", resp.StatusCode, len(mux.Journal().Requests()))
+	}
+}
+
+func TestAdmin_RejectsFixtureFiles(t *testing.T) {
+	mux := NewMux(WithAdmin("/__admin"))
+
+	for _, fixture := range []string{"{file: /etc/passwd}", "{dir: /etc, param: name}"} {
+		w := adminRequest(t, mux, "POST", "/endpoints", "method: GET\npath: /leak/{name}\nresponse_format: bytes\nfixture: "+fixture+"\n")
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "fixture") {
+			t.Errorf("expected fixture %s to be rejected but got %d %q", fixture, w.Code, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/leak/passwd", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected no endpoint to be registered but got status code %d", w.Code)
+	}
+}
+
+func TestAdmin_ReservedPrefix(t *testing.T) {
+	mux := NewMux(WithAdmin("/__admin"))
+
+	for _, path := range []string{"/__admin", "/__admin/routes"} {
+		err := RegisterEndpoint[User](mux, EndpointConfig{Method: "GET", Path: path, ResponseFormat: JSON})
+		if err == nil || !strings.Contains(err.Error(), "reserved") {
+			t.Errorf("expected %s to be reserved but got %v", path, err)
+		}
+	}
+
+	w := adminRequest(t, mux, "POST", "/endpoints", "method: GET\npath: /__admin/routes\nresponse_format: json\nresponse: {}\n")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status code %d but got %d", http.StatusBadRequest, w.Code)
+	}
+
+	if w := adminRequest(t, mux, "GET", "/routes", ""); w.Body.String() != "[]\n" {
+		t.Errorf("expected the admin API to keep serving its routes but got %q", w.Body.String())
+	}
+
+	// paths merely sharing the prefix are not reserved
+	if err := RegisterEndpoint[User](mux, EndpointConfig{Method: "GET", Path: "/__administrators", ResponseFormat: JSON}); err != nil {
+		t.Errorf("failed to register endpoint: %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+// Command fauxmux serves the fake endpoints described in a fauxmux configuration file.
+//
+// Usage:
+//
+//	fauxmux -config fake-service.yaml [-addr :8080] [-seed 42] [-latency-multiplier 0.5] [-log-format json]
+//	        [-admin-prefix /__admin | -admin-addr :9090] [-journal-limit 10000]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ullauri/fauxmux"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "fauxmux: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("fauxmux", flag.ContinueOnError)
+	configPath := flags.String("config", "", "path to the YAML or JSON endpoint configuration file (required)")
+	addr := flags.String("addr", ":8080", "address to listen on")
+	seed := flags.Int64("seed", 0, "seed of the random source (default derived from the current time)")
+	latencyMultiplier := flags.Float64("latency-multiplier", 1, "factor applied to every injected latency")
+	logFormat := flags.String("log-format", "text", "log format, text or json")
+	adminPrefix := flags.String("admin-prefix", "", "path prefix to serve the admin API under, e.g. /__admin")
+	adminAddr := flags.String("admin-addr", "", "address to serve the admin API on")
+	journalLimit := flags.Int("journal-limit", 10000, "number of most recent requests kept in the journal, 0 for no limit")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *configPath == "" {
+		return fmt.Errorf("-config is required")
+	}
+
+	if *latencyMultiplier < 0 {
+		return fmt.Errorf("-latency-multiplier cannot be negative")
+	}
+
+	if *adminPrefix != "" && *adminAddr != "" {
+		return fmt.Errorf("-admin-prefix and -admin-addr cannot both be set")
+	}
+
+	if *journalLimit < 0 {
+		return fmt.Errorf("-journal-limit cannot be negative")
+	}
+
+	logger, err := newLogger(*logFormat)
+	if err != nil {
+		return err
+	}
+
+	opts := []fauxmux.Option{fauxmux.WithLatencyMultiplier(*latencyMultiplier), fauxmux.WithJournalLimit(*journalLimit)}
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			opts = append(opts, fauxmux.WithSeed(*seed))
+		}
+	})
+	if *adminPrefix != "" {
+		opts = append(opts, fauxmux.WithAdmin(*adminPrefix))
+	}
+
+	mux := fauxmux.NewMux(opts...)
+	if err := fauxmux.LoadConfigFile(mux, *configPath); err != nil {
+		return err
+	}
+
+	if *adminAddr != "" {
+		go func() {
+			logger.Info("fauxmux admin API listening", "addr", *adminAddr)
+			if err := http.ListenAndServe(*adminAddr, logRequests(logger, mux.AdminHandler())); err != nil {
+				logger.Error("admin API stopped", "error", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	logger.Info("fauxmux listening", "addr", *addr, "config", *configPath, "seed", mux.Seed(), "routes", len(mux.Routes()))
+	return http.ListenAndServe(*addr, logRequests(logger, mux))
+}
+
+func newLogger(format string) (*slog.Logger, error) {
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, nil)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, nil)), nil
+	default:
+		return nil, fmt.Errorf("invalid -log-format %q, expected text or json", format)
+	}
+}
+
+// statusRecorder captures the status code written by a handler, 0 if it wrote nothing
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// logRequests logs every request served by next
+func logRequests(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 && r.Context().Err() != nil {
+			logger.Info("request cancelled", "method", r.Method, "path", r.URL.Path, "duration", time.Since(start))
+			return
+		}
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		logger.Info("request", "method", r.Method, "path", r.URL.Path, "status", rec.status, "duration", time.Since(start))
+	})
+}
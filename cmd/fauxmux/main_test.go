@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfig = `endpoints:
+  - method: GET
+    path: /status
+    response_format: json
+    response: {status: ok}
+`
+
+func TestRun_Errors(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	if err := os.WriteFile(valid, []byte(testConfig), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte(strings.Replace(testConfig, "json", "jsonp", 1)), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "missing config", args: []string{}, wantErr: "-config is required"},
+		{name: "negative latency multiplier", args: []string{"-config", valid, "-latency-multiplier", "-1"}, wantErr: "-latency-multiplier cannot be negative"},
+		{name: "both admin flags", args: []string{"-config", valid, "-admin-prefix", "/__admin", "-admin-addr", ":9090"}, wantErr: "-admin-prefix and -admin-addr cannot both be set"},
+		{name: "negative journal limit", args: []string{"-config", valid, "-journal-limit", "-1"}, wantErr: "-journal-limit cannot be negative"},
+		{name: "bad log format", args: []string{"-config", valid, "-log-format", "xml"}, wantErr: `invalid -log-format "xml"`},
+		{name: "unknown flag", args: []string{"-config", valid, "-verbose"}, wantErr: "flag provided but not defined"},
+		{name: "missing config file", args: []string{"-config", filepath.Join(dir, "missing.yaml")}, wantErr: "missing.yaml"},
+		{name: "invalid config file", args: []string{"-config", invalid}, wantErr: "endpoints[0].response_format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := run(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q but got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestRun_LoadsConfig(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "fauxmux.yaml")
+	if err := os.WriteFile(config, []byte(testConfig), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	// an address that cannot be listened on makes run return once the config is loaded
+	err := run([]string{"-config", config, "-addr", "invalid:address", "-seed", "1", "-admin-prefix", "/__admin"})
+	if err == nil || !strings.Contains(err.Error(), "listen tcp") {
+		t.Errorf("expected the config to load and listening to fail but got %v", err)
+	}
+}
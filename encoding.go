@@ -0,0 +1,411 @@
+package fauxmux
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// Encoder encodes response values for a ResponseFormat
+type Encoder interface {
+	// ContentType returns the content type of the encoded responses
+	ContentType() string
+	// Encode writes the encoding of v to w
+	Encode(w io.Writer, v any) error
+}
+
+// EncoderFunc returns an Encoder with the given content type that encodes values with encode
+func EncoderFunc(contentType string, encode func(w io.Writer, v any) error) Encoder {
+	return encoderFunc{contentType: contentType, encode: encode}
+}
+
+type encoderFunc struct {
+	contentType string
+	encode      func(w io.Writer, v any) error
+}
+
+func (e encoderFunc) ContentType() string {
+	return e.contentType
+}
+
+func (e encoderFunc) Encode(w io.Writer, v any) error {
+	return e.encode(w, v)
+}
+
+var encoders = map[ResponseFormat]Encoder{
+	JSON:        EncoderFunc("application/json", encodeJSON),
+	Bytes:       EncoderFunc("application/octet-stream", encodeBytes),
+	XML:         EncoderFunc("application/xml", encodeXML),
+	YAML:        EncoderFunc("application/yaml", encodeYAML),
+	MessagePack: EncoderFunc("application/msgpack", encodeMessagePack),
+	CSV:         EncoderFunc("text/csv", encodeCSV),
+	Text:        EncoderFunc("text/plain; charset=utf-8", encodeText),
+}
+var encodersMutex sync.RWMutex
+
+// RegisterEncoder makes enc available to endpoints and error responses using format,
+// replacing any encoder previously registered for it
+func RegisterEncoder(format ResponseFormat, enc Encoder) {
+	encodersMutex.Lock()
+	defer encodersMutex.Unlock()
+	encoders[format] = enc
+}
+
+func lookupEncoder(format ResponseFormat) (Encoder, bool) {
+	encodersMutex.RLock()
+	defer encodersMutex.RUnlock()
+	enc, ok := encoders[format]
+	return enc, ok
+}
+
+// encodeResponse returns the encoding of v in format and its content type
+func encodeResponse(format ResponseFormat, v any) ([]byte, string, error) {
+	enc, ok := lookupEncoder(format)
+	if !ok {
+		return nil, "", ErrInvalidResponseFormat
+	}
+
+	var buf bytes.Buffer
+	if err := enc.Encode(&buf, v); err != nil {
+		return nil, "", err
+	}
+
+	contentType := enc.ContentType()
+	if _, ok := v.(string); ok && format == Bytes {
+		// raw strings are text rather than opaque bytes
+		contentType = "text/plain; charset=utf-8"
+	}
+	return buf.Bytes(), contentType, nil
+}
+
+func encodeJSON(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func encodeBytes(w io.Writer, v any) error {
+	switch v := indirect(v).(type) {
+	case []byte:
+		_, err := w.Write(v)
+		return err
+	case string:
+		_, err := io.WriteString(w, v)
+		return err
+	default:
+		return fmt.Errorf("bytes response must be a []byte or string, got %T", v)
+	}
+}
+
+func encodeYAML(w io.Writer, v any) error {
+	enc := yaml.NewEncoder(w)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func encodeMessagePack(w io.Writer, v any) error {
+	enc := msgpack.NewEncoder(w)
+	// field names match the JSON encoding of the same response
+	enc.SetCustomStructTag("json")
+	return enc.Encode(v)
+}
+
+func encodeText(w io.Writer, v any) error {
+	switch v := indirect(v).(type) {
+	case []byte:
+		_, err := w.Write(v)
+		return err
+	case fmt.Stringer:
+		_, err := io.WriteString(w, v.String())
+		return err
+	default:
+		_, err := fmt.Fprintf(w, "%v", v)
+		return err
+	}
+}
+
+// indirect dereferences pointers so that *T is encoded like T
+func indirect(v any) any {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		if _, ok := rv.Interface().(fmt.Stringer); ok {
+			break
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return v
+	}
+	return rv.Interface()
+}
+
+func encodeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if err := enc.Encode(xmlValue(v)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// xmlValue wraps values that encoding/xml cannot encode as a document on their own:
+// lists, which lack a root element, and the maps decoded from configuration files
+func xmlValue(v any) any {
+	rv := reflect.ValueOf(indirect(v))
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		return xmlElement{name: "items", value: rv}
+	case reflect.Map:
+		return xmlElement{name: "response", value: rv}
+	default:
+		return v
+	}
+}
+
+// xmlElement encodes a list or map as an element named name
+type xmlElement struct {
+	name  string
+	value reflect.Value
+}
+
+func (x xmlElement) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	return encodeXMLElement(enc, x.name, x.value)
+}
+
+func encodeXMLElement(enc *xml.Encoder, name string, v reflect.Value) error {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return enc.EncodeElement("", xml.StartElement{Name: xml.Name{Local: name}})
+		}
+		v = v.Elem()
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	switch v.Kind() {
+	case reflect.Map:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int {
+			return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+		})
+		for _, key := range keys {
+			if err := encodeXMLElement(enc, fmt.Sprint(key.Interface()), v.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return enc.EncodeElement(v.Interface(), start)
+		}
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i)
+			if indirectKind(item) == reflect.Struct {
+				// structs are named by their type or XMLName field
+				if err := enc.Encode(item.Interface()); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := encodeXMLElement(enc, "item", item); err != nil {
+				return err
+			}
+		}
+		return enc.EncodeToken(start.End())
+	default:
+		return enc.EncodeElement(v.Interface(), start)
+	}
+}
+
+func indirectKind(v reflect.Value) reflect.Kind {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return v.Kind()
+		}
+		v = v.Elem()
+	}
+	return v.Kind()
+}
+
+// encodeCSV encodes a list of structs or maps as a header row followed by one row per item.
+// A single struct or map is encoded as a one-row list.
+func encodeCSV(w io.Writer, v any) error {
+	rv := reflect.ValueOf(indirect(v))
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		rv = reflect.ValueOf([]any{v})
+	}
+
+	rows := make([]reflect.Value, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		item := rv.Index(i)
+		for item.Kind() == reflect.Interface || item.Kind() == reflect.Pointer {
+			item = item.Elem()
+		}
+		rows = append(rows, item)
+	}
+
+	header, err := csvHeader(rows)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if header != nil {
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+	}
+	for _, row := range rows {
+		record, err := csvRecord(row, header)
+		if err != nil {
+			return err
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvHeader returns the column names of rows: the JSON names of struct fields or the union of map keys.
+// Rows of scalars or lists have no header.
+func csvHeader(rows []reflect.Value) ([]string, error) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	switch rows[0].Kind() {
+	case reflect.Struct:
+		if rows[0].Type() == reflect.TypeOf(time.Time{}) {
+			return nil, nil
+		}
+		header := make([]string, 0)
+		for _, field := range csvFields(rows[0].Type()) {
+			header = append(header, field.name)
+		}
+		return header, nil
+	case reflect.Map:
+		seen := map[string]bool{}
+		header := make([]string, 0)
+		for _, row := range rows {
+			if row.Kind() != reflect.Map {
+				return nil, fmt.Errorf("csv rows must all be maps")
+			}
+			for _, key := range row.MapKeys() {
+				name := fmt.Sprint(key.Interface())
+				if !seen[name] {
+					seen[name] = true
+					header = append(header, name)
+				}
+			}
+		}
+		slices.Sort(header)
+		return header, nil
+	default:
+		return nil, nil
+	}
+}
+
+func csvRecord(row reflect.Value, header []string) ([]string, error) {
+	switch {
+	case row.Kind() == reflect.Struct && header != nil:
+		record := make([]string, 0, len(header))
+		for _, field := range csvFields(row.Type()) {
+			record = append(record, csvCell(row.FieldByIndex(field.index)))
+		}
+		return record, nil
+	case row.Kind() == reflect.Map:
+		record := make([]string, 0, len(header))
+		for _, name := range header {
+			cell := row.MapIndex(reflect.ValueOf(name).Convert(row.Type().Key()))
+			if !cell.IsValid() {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, csvCell(cell))
+		}
+		return record, nil
+	case row.Kind() == reflect.Slice || row.Kind() == reflect.Array:
+		record := make([]string, 0, row.Len())
+		for i := 0; i < row.Len(); i++ {
+			record = append(record, csvCell(row.Index(i)))
+		}
+		return record, nil
+	case !row.IsValid():
+		return []string{""}, nil
+	default:
+		return []string{csvCell(row)}, nil
+	}
+}
+
+type csvField struct {
+	name  string
+	index []int
+}
+
+// csvFields returns the exported fields of t named by their JSON name
+func csvFields(t reflect.Type) []csvField {
+	fields := make([]csvField, 0)
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, csvField{name: name, index: field.Index})
+	}
+	return fields
+}
+
+// csvCell formats a scalar as text and nested values as JSON
+func csvCell(v reflect.Value) string {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return fmt.Sprint(v.Interface())
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
@@ -0,0 +1,165 @@
+package fauxmux
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestEncodeResponse(t *testing.T) {
+	users := []User{{ID: 1, Name: "Doe", Email: "doe@testing.com"}, {ID: 2, Name: "Roe, Jr.", Email: "roe@testing.com"}}
+
+	tests := []struct {
+		name            string
+		format          ResponseFormat
+		value           any
+		wantBody        string
+		wantContentType string
+		wantErr         bool
+	}{
+		{
+			name:            "json",
+			format:          JSON,
+			value:           &users[0],
+			wantBody:        `{"id":1,"name":"Doe","email":"doe@testing.com"}` + "\n",
+			wantContentType: "application/json",
+		},
+		{
+			name:            "xml struct",
+			format:          XML,
+			value:           &users[0],
+			wantBody:        "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<User><ID>1</ID><Name>Doe</Name><Email>doe@testing.com</Email></User>\n",
+			wantContentType: "application/xml",
+		},
+		{
+			name:            "xml list",
+			format:          XML,
+			value:           users[:1],
+			wantBody:        "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<items><User><ID>1</ID><Name>Doe</Name><Email>doe@testing.com</Email></User></items>\n",
+			wantContentType: "application/xml",
+		},
+		{
+			name:            "xml map",
+			format:          XML,
+			value:           map[string]any{"status": "ok", "tags": []any{"a", "b"}},
+			wantBody:        "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<response><status>ok</status><tags><item>a</item><item>b</item></tags></response>\n",
+			wantContentType: "application/xml",
+		},
+		{
+			name:            "yaml",
+			format:          YAML,
+			value:           map[string]any{"id": 1, "name": "Doe"},
+			wantBody:        "id: 1\nname: Doe\n",
+			wantContentType: "application/yaml",
+		},
+		{
+			name:            "csv structs",
+			format:          CSV,
+			value:           users,
+			wantBody:        "id,name,email\n1,Doe,doe@testing.com\n2,\"Roe, Jr.\",roe@testing.com\n",
+			wantContentType: "text/csv",
+		},
+		{
+			name:            "csv single struct",
+			format:          CSV,
+			value:           &users[0],
+			wantBody:        "id,name,email\n1,Doe,doe@testing.com\n",
+			wantContentType: "text/csv",
+		},
+		{
+			name:            "csv maps",
+			format:          CSV,
+			value:           []any{map[string]any{"b": 2, "a": 1}, map[string]any{"c": []int{3}}},
+			wantBody:        "a,b,c\n1,2,\n,,[3]\n",
+			wantContentType: "text/csv",
+		},
+		{
+			name:            "text",
+			format:          Text,
+			value:           &users[0],
+			wantBody:        "{1 Doe doe@testing.com}",
+			wantContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:            "bytes",
+			format:          Bytes,
+			value:           []byte("raw"),
+			wantBody:        "raw",
+			wantContentType: "application/octet-stream",
+		},
+		{
+			name:    "bytes of struct",
+			format:  Bytes,
+			value:   &users[0],
+			wantErr: true,
+		},
+		{
+			name:    "unregistered format",
+			format:  "toml",
+			value:   &users[0],
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType, err := encodeResponse(tt.format, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("encodeResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if string(body) != tt.wantBody {
+				t.Errorf("encodeResponse() body = %q, want %q", body, tt.wantBody)
+			}
+			if contentType != tt.wantContentType {
+				t.Errorf("encodeResponse() content type = %v, want %v", contentType, tt.wantContentType)
+			}
+		})
+	}
+}
+
+func TestEncodeResponse_MessagePack(t *testing.T) {
+	body, contentType, err := encodeResponse(MessagePack, &User{ID: 1, Name: "Doe", Email: "doe@testing.com"})
+	if err != nil {
+		t.Fatalf("encodeResponse() error = %v", err)
+	}
+	if contentType != "application/msgpack" {
+		t.Errorf("encodeResponse() content type = %v, want application/msgpack", contentType)
+	}
+
+	var decoded map[string]any
+	if err := msgpack.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("failed to decode message pack: %v", err)
+	}
+	if decoded["name"] != "Doe" || decoded["email"] != "doe@testing.com" {
+		t.Errorf("encodeResponse() decoded = %v, want JSON field names", decoded)
+	}
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	RegisterEncoder("shout", EncoderFunc("text/x-shout", func(w io.Writer, v any) error {
+		_, err := io.WriteString(w, strings.ToUpper(v.(*User).Name))
+		return err
+	}))
+
+	mux := NewMux()
+	err := RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/users",
+		ResponseFormat: "shout",
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+	if w.Body.String() != "DOE" || w.Header().Get("Content-Type") != "text/x-shout" {
+		t.Fatalf("expected custom encoding but got %q (%s)", w.Body.String(), w.Header().Get("Content-Type"))
+	}
+}
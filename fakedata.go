@@ -0,0 +1,424 @@
+package fauxmux
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fakeDataTag is the struct tag steering the built-in fake data generator. Its options are
+// separated by semicolons:
+//
+//	Email string   `fauxmux:"email"`        a kind of string, see fakeStringKinds
+//	Age   int      `fauxmux:"range=18,99"`  a number within min and max, inclusive
+//	Role  string   `fauxmux:"oneof=a|b"`    one of the values
+//	Code  string   `fauxmux:"len=5"`        a string of 5 characters, or a slice or map of 5 items
+//	Tags  []string `fauxmux:"len=2;word"`   options other than len apply to the items of a slice or map
+//	Note  string   `fauxmux:"-"`            left to its zero value
+const fakeDataTag = "fauxmux"
+
+// maxFakeDepth bounds the nesting of the generated values, leaving recursive types finite
+const maxFakeDepth = 5
+
+// fakeStringKinds generates the kinds of strings a field can be tagged with, which are also the
+// string formats of the schemas of configuration files
+var fakeStringKinds = map[string]func(rng *rand.Rand) string{
+	"email": func(rng *rand.Rand) string {
+		return strings.ToLower(pick(rng, fakeFirstNames)) + "." + strings.ToLower(pick(rng, fakeLastNames)) + "@" + pick(rng, fakeDomains)
+	},
+	"uuid": func(rng *rand.Rand) string {
+		// rng.Read is not safe for concurrent use, even with a locked source
+		b := binary.BigEndian.AppendUint64(binary.BigEndian.AppendUint64(nil, rng.Uint64()), rng.Uint64())
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	},
+	"name": func(rng *rand.Rand) string {
+		return pick(rng, fakeFirstNames) + " " + pick(rng, fakeLastNames)
+	},
+	"first_name": func(rng *rand.Rand) string {
+		return pick(rng, fakeFirstNames)
+	},
+	"last_name": func(rng *rand.Rand) string {
+		return pick(rng, fakeLastNames)
+	},
+	"username": func(rng *rand.Rand) string {
+		return strings.ToLower(pick(rng, fakeFirstNames)) + strconv.Itoa(rng.Intn(1000))
+	},
+	"url": func(rng *rand.Rand) string {
+		return "https://" + pick(rng, fakeDomains) + "/" + pick(rng, fakeWords)
+	},
+	"phone": func(rng *rand.Rand) string {
+		return fmt.Sprintf("+1-%03d-%03d-%04d", 200+rng.Intn(800), rng.Intn(1000), rng.Intn(10000))
+	},
+	"ipv4": func(rng *rand.Rand) string {
+		return fmt.Sprintf("%d.%d.%d.%d", 1+rng.Intn(254), rng.Intn(256), rng.Intn(256), 1+rng.Intn(254))
+	},
+	"word": func(rng *rand.Rand) string {
+		return pick(rng, fakeWords)
+	},
+	"sentence": func(rng *rand.Rand) string {
+		words := make([]string, 4+rng.Intn(6))
+		for i := range words {
+			words[i] = pick(rng, fakeWords)
+		}
+		sentence := strings.Join(words, " ")
+		return strings.ToUpper(sentence[:1]) + sentence[1:] + "."
+	},
+}
+
+var (
+	fakeFirstNames = []string{"Alice", "Bob", "Carol", "Dave", "Eve", "Frank", "Grace", "Heidi", "Ivan", "Judy", "Mallory", "Oscar", "Peggy", "Trent", "Victor", "Wendy"}
+	fakeLastNames  = []string{"Smith", "Johnson", "Garcia", "Miller", "Davis", "Lopez", "Wilson", "Anderson", "Taylor", "Moore", "Martin", "Lee", "Walker", "Young"}
+	fakeDomains    = []string{"example.com", "example.org", "example.net", "test.com"}
+	fakeWords      = []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel", "india", "juliet", "kilo", "lima", "mike", "november", "oscar", "papa", "quebec", "romeo", "sierra", "tango"}
+)
+
+// fakeFieldKinds infers the kind of the untagged string fields named after one
+var fakeFieldKinds = map[string]string{
+	"email":     "email",
+	"uuid":      "uuid",
+	"name":      "name",
+	"firstname": "first_name",
+	"lastname":  "last_name",
+	"username":  "username",
+	"url":       "url",
+	"phone":     "phone",
+}
+
+const fakeLetters = "abcdefghijklmnopqrstuvwxyz"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func pick(rng *rand.Rand, values []string) string {
+	return values[rng.Intn(len(values))]
+}
+
+// fakeOptions are the parsed options of a fakeDataTag
+type fakeOptions struct {
+	kind   string
+	skip   bool
+	length *int
+	min    *float64
+	max    *float64
+	oneOf  []string
+}
+
+func parseFakeOptions(tag string) (fakeOptions, error) {
+	var opts fakeOptions
+	if tag == "-" {
+		opts.skip = true
+		return opts, nil
+	}
+
+	for _, option := range strings.Split(tag, ";") {
+		option = strings.TrimSpace(option)
+		key, value, hasValue := strings.Cut(option, "=")
+		switch {
+		case option == "":
+		case key == "len" && hasValue:
+			length, err := strconv.Atoi(value)
+			if err != nil || length < 0 {
+				return opts, fmt.Errorf("invalid len %q", value)
+			}
+			opts.length = &length
+		case key == "range" && hasValue:
+			minValue, maxValue, ok := strings.Cut(value, ",")
+			min, minErr := strconv.ParseFloat(strings.TrimSpace(minValue), 64)
+			max, maxErr := strconv.ParseFloat(strings.TrimSpace(maxValue), 64)
+			if !ok || minErr != nil || maxErr != nil || min > max {
+				return opts, fmt.Errorf("invalid range %q", value)
+			}
+			opts.min, opts.max = &min, &max
+		case key == "oneof" && hasValue:
+			opts.oneOf = strings.Split(value, "|")
+		case !hasValue && fakeStringKinds[option] != nil:
+			opts.kind = option
+		default:
+			return opts, fmt.Errorf("invalid option %q", option)
+		}
+	}
+	return opts, nil
+}
+
+// itemOptions returns the options applying to the items of a slice, array or map
+func (o fakeOptions) itemOptions() fakeOptions {
+	o.length = nil
+	return o
+}
+
+// newFakeDataFunc returns the built-in FakeDataFunc, drawing from rng. It fills the value v points
+// to, recursing into structs, pointers, slices, arrays and maps and steered by fakeDataTag.
+func newFakeDataFunc(rng *rand.Rand) FakeDataFunc {
+	return func(v interface{}) error {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Pointer || rv.IsNil() {
+			return fmt.Errorf("fake data requires a non-nil pointer, got %T", v)
+		}
+		return fakeValue(rng, rv.Elem(), fakeOptions{}, 0)
+	}
+}
+
+// fakeRand is the random source of FakeData
+var fakeRand = newRand(time.Now().UnixNano())
+
+// FakeData is the built-in FakeDataFunc, used by the Muxes without any other. It needs no
+// dependency: fields are filled according to their type and fakeDataTag, as in
+//
+//	type User struct {
+//		ID    string   `fauxmux:"uuid"`
+//		Email string   `fauxmux:"email"`
+//		Age   int      `fauxmux:"range=18,99"`
+//		Role  string   `fauxmux:"oneof=admin|member"`
+//		Tags  []string `fauxmux:"len=2;word"`
+//	}
+//
+// Untagged string fields named like a kind of string, such as Email or Name, get that kind.
+// A Mux draws the data of its endpoints from its own random source, following its seed.
+func FakeData(v interface{}) error {
+	return newFakeDataFunc(fakeRand)(v)
+}
+
+func fakeValue(rng *rand.Rand, v reflect.Value, opts fakeOptions, depth int) error {
+	if opts.skip {
+		return nil
+	}
+
+	if len(opts.oneOf) > 0 && v.Kind() != reflect.Slice && v.Kind() != reflect.Array && v.Kind() != reflect.Map {
+		return setFakeString(v, pick(rng, opts.oneOf))
+	}
+
+	if v.Type() == timeType {
+		// within the last year, to the second
+		ago := time.Duration(rng.Int63n(int64(365 * 24 * time.Hour)))
+		v.Set(reflect.ValueOf(time.Now().UTC().Add(-ago).Truncate(time.Second)))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(fakeString(rng, opts))
+	case reflect.Bool:
+		v.SetBool(rng.Intn(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		lo, hi, err := fakeIntegerRange(opts, v.Type())
+		if err != nil {
+			return err
+		}
+		min, max := saturateInt64(lo), saturateInt64(hi)
+		// the span of a range of int64 may not fit an int64, but always fits an uint64
+		v.SetInt(min + int64(randUint64n(rng, uint64(max)-uint64(min))))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		lo, hi, err := fakeIntegerRange(opts, v.Type())
+		if err != nil {
+			return err
+		}
+		min, max := saturateUint64(lo), saturateUint64(hi)
+		v.SetUint(min + randUint64n(rng, max-min))
+	case reflect.Float32, reflect.Float64:
+		min, max := fakeRange(opts, 0, 1000)
+		// two decimals, like an amount
+		v.SetFloat(float64(int64((min+rng.Float64()*(max-min))*100)) / 100)
+	case reflect.Pointer:
+		if depth >= maxFakeDepth {
+			return nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		if err := fakeValue(rng, elem.Elem(), opts, depth+1); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Slice:
+		if depth >= maxFakeDepth {
+			return nil
+		}
+		n := fakeLength(rng, opts)
+		slice := reflect.MakeSlice(v.Type(), n, n)
+		for i := 0; i < n; i++ {
+			if err := fakeValue(rng, slice.Index(i), opts.itemOptions(), depth+1); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := fakeValue(rng, v.Index(i), opts.itemOptions(), depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if depth >= maxFakeDepth {
+			return nil
+		}
+		n := fakeLength(rng, opts)
+		m := reflect.MakeMapWithSize(v.Type(), n)
+		for i := 0; i < n; i++ {
+			key := reflect.New(v.Type().Key()).Elem()
+			if err := fakeValue(rng, key, fakeOptions{}, depth+1); err != nil {
+				return err
+			}
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := fakeValue(rng, value, opts.itemOptions(), depth+1); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, value)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		return fakeStruct(rng, v, depth)
+	}
+	// interfaces, channels, functions and complex numbers are left to their zero value
+	return nil
+}
+
+func fakeStruct(rng *rand.Rand, v reflect.Value, depth int) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		opts, err := parseFakeOptions(field.Tag.Get(fakeDataTag))
+		if err != nil {
+			return fmt.Errorf("field %s of %s: %v", field.Name, t, err)
+		}
+		if opts.kind == "" && field.Type.Kind() == reflect.String {
+			opts.kind = fakeFieldKinds[strings.ToLower(strings.ReplaceAll(field.Name, "_", ""))]
+		}
+
+		if err := fakeValue(rng, v.Field(i), opts, depth+1); err != nil {
+			return fmt.Errorf("field %s of %s: %v", field.Name, t, err)
+		}
+	}
+	return nil
+}
+
+func fakeString(rng *rand.Rand, opts fakeOptions) string {
+	if opts.length != nil {
+		b := make([]byte, *opts.length)
+		for i := range b {
+			b[i] = fakeLetters[rng.Intn(len(fakeLetters))]
+		}
+		return string(b)
+	}
+	if opts.kind != "" {
+		return fakeStringKinds[opts.kind](rng)
+	}
+	return pick(rng, fakeWords)
+}
+
+// fakeRange returns the range of the numbers generated, defaulting to min and max
+func fakeRange(opts fakeOptions, min, max float64) (float64, float64) {
+	if opts.min != nil {
+		return *opts.min, *opts.max
+	}
+	return min, max
+}
+
+// fakeIntegerRange returns the range of the integers of type t generated, 1 to 1000 by default,
+// kept within the bounds of t. It fails if the range holds no integer of type t.
+func fakeIntegerRange(opts fakeOptions, t reflect.Type) (float64, float64, error) {
+	min, max := fakeRange(opts, 1, 1000)
+
+	lower, upper := -math.Exp2(float64(t.Bits()-1)), math.Exp2(float64(t.Bits()-1))-1
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		lower, upper = 0, math.Exp2(float64(t.Bits()))-1
+	}
+
+	lo, hi := math.Max(lower, math.Ceil(min)), math.Min(upper, math.Floor(max))
+	if lo > hi {
+		return 0, 0, fmt.Errorf("range %v,%v holds no %s", min, max, t)
+	}
+	return lo, hi, nil
+}
+
+// saturateInt64 converts the integer f to an int64, saturating at the bounds of int64, which
+// float64 cannot represent exactly
+func saturateInt64(f float64) int64 {
+	switch {
+	case f >= math.Exp2(63):
+		return math.MaxInt64
+	case f <= -math.Exp2(63):
+		return math.MinInt64
+	}
+	return int64(f)
+}
+
+// saturateUint64 converts the non-negative integer f to an uint64, saturating at its upper bound
+func saturateUint64(f float64) uint64 {
+	if f >= math.Exp2(64) {
+		return math.MaxUint64
+	}
+	return uint64(f)
+}
+
+// randUint64n returns a uniformly distributed number within 0 and n, inclusive
+func randUint64n(rng *rand.Rand, n uint64) uint64 {
+	if n == math.MaxUint64 {
+		return rng.Uint64()
+	}
+	n++
+	// reject the lowest values, which would make the remainders below 2^64 mod n more likely
+	threshold := -n % n
+	for {
+		if x := rng.Uint64(); x >= threshold {
+			return x % n
+		}
+	}
+}
+
+// fakeLength returns the number of items of a slice or map, 1 to 5 unless set with len
+func fakeLength(rng *rand.Rand, opts fakeOptions) int {
+	if opts.length != nil {
+		return *opts.length
+	}
+	return 1 + rng.Intn(5)
+}
+
+// setFakeString sets v to s, parsed according to the kind of v
+func setFakeString(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid oneof value %q: %v", s, err)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid oneof value %q: %v", s, err)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid oneof value %q: %v", s, err)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid oneof value %q: %v", s, err)
+		}
+		v.SetFloat(f)
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := setFakeString(elem.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(elem)
+	default:
+		return fmt.Errorf("oneof is not supported for %s", v.Type())
+	}
+	return nil
+}
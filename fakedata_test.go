@@ -0,0 +1,201 @@
+package fauxmux
+
+import (
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeAddress struct {
+	Street string
+	Zip    string `fauxmux:"len=5"`
+}
+
+type fakeCustomer struct {
+	ID        string            `fauxmux:"uuid"`
+	Email     string            `json:"email"`
+	Age       int               `fauxmux:"range=18,99"`
+	Score     float64           `fauxmux:"range=0,1"`
+	Level     uint8             `fauxmux:"range=1,3"`
+	Role      string            `fauxmux:"oneof=admin|member"`
+	Priority  int               `fauxmux:"oneof=1|5|10"`
+	Tags      []string          `fauxmux:"len=3;oneof=a|b"`
+	Labels    map[string]string `fauxmux:"len=2"`
+	Address   *fakeAddress
+	Addresses []fakeAddress `fauxmux:"len=2"`
+	CreatedAt time.Time
+	Ignored   string `fauxmux:"-"`
+	Referrer  *fakeCustomer
+	internal  string
+}
+
+func TestFakeData(t *testing.T) {
+	var customer fakeCustomer
+	if err := newFakeDataFunc(newRand(1))(&customer); err != nil {
+		t.Fatalf("failed to fake data: %v", err)
+	}
+
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(customer.ID) {
+		t.Errorf("expected a uuid but got %q", customer.ID)
+	}
+	if !strings.Contains(customer.Email, "@") {
+		t.Errorf("expected an email inferred from the field name but got %q", customer.Email)
+	}
+	if customer.Age < 18 || customer.Age > 99 {
+		t.Errorf("expected an age within 18 and 99 but got %d", customer.Age)
+	}
+	if customer.Score < 0 || customer.Score > 1 {
+		t.Errorf("expected a score within 0 and 1 but got %v", customer.Score)
+	}
+	if customer.Level < 1 || customer.Level > 3 {
+		t.Errorf("expected a level within 1 and 3 but got %d", customer.Level)
+	}
+	if customer.Role != "admin" && customer.Role != "member" {
+		t.Errorf("expected one of the roles but got %q", customer.Role)
+	}
+	if !slices.Contains([]int{1, 5, 10}, customer.Priority) {
+		t.Errorf("expected one of the priorities but got %d", customer.Priority)
+	}
+	if len(customer.Tags) != 3 || slices.ContainsFunc(customer.Tags, func(tag string) bool { return tag != "a" && tag != "b" }) {
+		t.Errorf("expected 3 tags of a and b but got %q", customer.Tags)
+	}
+	if len(customer.Labels) != 2 {
+		t.Errorf("expected 2 labels but got %v", customer.Labels)
+	}
+	if customer.Address == nil || customer.Address.Street == "" || len(customer.Address.Zip) != 5 {
+		t.Errorf("expected an address but got %+v", customer.Address)
+	}
+	if len(customer.Addresses) != 2 || customer.Addresses[1].Street == "" {
+		t.Errorf("expected 2 addresses but got %+v", customer.Addresses)
+	}
+	if customer.CreatedAt.IsZero() || customer.CreatedAt.After(time.Now()) {
+		t.Errorf("expected a past time but got %v", customer.CreatedAt)
+	}
+	if customer.Ignored != "" || customer.internal != "" {
+		t.Errorf("expected skipped and unexported fields to be left empty but got %q and %q", customer.Ignored, customer.internal)
+	}
+
+	// the nesting of recursive types is bounded
+	depth := 0
+	for referrer := customer.Referrer; referrer != nil; referrer = referrer.Referrer {
+		depth++
+	}
+	if depth == 0 || depth > maxFakeDepth {
+		t.Errorf("expected a bounded chain of referrers but got %d", depth)
+	}
+}
+
+func TestFakeData_Seed(t *testing.T) {
+	var first, second []fakeCustomer
+	if err := newFakeDataFunc(newRand(7))(&first); err != nil {
+		t.Fatalf("failed to fake data: %v", err)
+	}
+	if err := newFakeDataFunc(newRand(7))(&second); err != nil {
+		t.Fatalf("failed to fake data: %v", err)
+	}
+	// times are relative to now
+	for i := range first {
+		first[i].CreatedAt, second[i].CreatedAt = time.Time{}, time.Time{}
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("expected identical data for identical seeds")
+	}
+}
+
+func TestFakeData_Concurrent(t *testing.T) {
+	// run with -race: FakeData shares a single random source
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				var customer fakeCustomer
+				if err := FakeData(&customer); err != nil {
+					t.Errorf("failed to fake data: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFakeData_WideRanges(t *testing.T) {
+	var wide struct {
+		Big     int64  `fauxmux:"range=-9000000000000000000,9000000000000000000"`
+		Huge    uint64 `fauxmux:"range=0,1e30"`
+		Clamped int8   `fauxmux:"range=-1000,1000"`
+		Shifted uint8  `fauxmux:"range=-10,2"`
+	}
+	fakeDataFunc := newFakeDataFunc(newRand(1))
+	for i := 0; i < 100; i++ {
+		if err := fakeDataFunc(&wide); err != nil {
+			t.Fatalf("failed to fake data: %v", err)
+		}
+		if wide.Big < -9000000000000000000 || wide.Big > 9000000000000000000 {
+			t.Errorf("expected a number within the range but got %d", wide.Big)
+		}
+		if wide.Shifted > 2 {
+			t.Errorf("expected a number within 0 and 2 but got %d", wide.Shifted)
+		}
+	}
+}
+
+func TestFakeDataFunc_BuiltInFallback(t *testing.T) {
+	mutex.Lock()
+	saved := config
+	config = Config{}
+	mutex.Unlock()
+	defer func() {
+		mutex.Lock()
+		config = saved
+		mutex.Unlock()
+	}()
+
+	var customer fakeCustomer
+	if err := NewMux().fakeDataFunc(EndpointConfig{}, newRand(1))(&customer); err != nil {
+		t.Fatalf("failed to fake data: %v", err)
+	}
+	if customer.Age < 18 || customer.Age > 99 || customer.Address == nil || len(customer.Tags) != 3 {
+		t.Errorf("expected the built-in generator to honor the tags but got %+v", customer)
+	}
+}
+
+func TestFakeData_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+	}{
+		{name: "not a pointer", v: fakeAddress{}},
+		{name: "invalid option", v: &struct {
+			Name string `fauxmux:"nickname"`
+		}{}},
+		{name: "invalid range", v: &struct {
+			Age int `fauxmux:"range=10,1"`
+		}{}},
+		{name: "no integer in range", v: &struct {
+			Age int `fauxmux:"range=1.5,1.7"`
+		}{}},
+		{name: "negative range of uint", v: &struct {
+			Level uint8 `fauxmux:"range=-5,-1"`
+		}{}},
+		{name: "invalid len", v: &struct {
+			Tags []string `fauxmux:"len=-1"`
+		}{}},
+		{name: "invalid oneof", v: &struct {
+			Age int `fauxmux:"oneof=young|old"`
+		}{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := FakeData(tt.v); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
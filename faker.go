@@ -16,9 +16,17 @@ func (c *Config) FakeData(v interface{}) error {
 var config Config
 var mutex sync.Mutex
 
-// Setup sets the configuration for the fauxmux package
+// Setup sets the package-wide configuration, the fallback of every Mux created without
+// WithFakeDataFunc. Prefer the options of NewMux, which don't affect other Muxes.
 func Setup(c Config) {
 	mutex.Lock()
 	defer mutex.Unlock()
 	config = c
 }
+
+// setupFakeDataFunc returns the FakeDataFunc set up with Setup
+func setupFakeDataFunc() FakeDataFunc {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return config.FakeDataFunc
+}
@@ -0,0 +1,208 @@
+package fauxmux
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// FaultKind is a transport-level failure, as opposed to a well-formed error response
+type FaultKind string
+
+const (
+	// FaultCloseConnection closes the connection without sending a response
+	FaultCloseConnection FaultKind = "close_connection"
+	// FaultConnectionReset aborts the connection with a TCP RST
+	FaultConnectionReset FaultKind = "connection_reset"
+	// FaultHang never responds, holding the connection open until the client gives up
+	FaultHang FaultKind = "hang"
+	// FaultHeadersThenClose sends the status line and headers, then closes the connection before the body
+	FaultHeadersThenClose FaultKind = "headers_then_close"
+	// FaultTruncatedBody sends half of the body, shorter than its Content-Length, then closes the connection
+	FaultTruncatedBody FaultKind = "truncated_body"
+	// FaultMalformedJSON sends a complete response whose JSON body is cut short
+	FaultMalformedJSON FaultKind = "malformed_json"
+)
+
+var faultKinds = []FaultKind{
+	FaultCloseConnection,
+	FaultConnectionReset,
+	FaultHang,
+	FaultHeadersThenClose,
+	FaultTruncatedBody,
+	FaultMalformedJSON,
+}
+
+// FaultConfig injects transport-level faults into a fraction of an endpoint's responses
+type FaultConfig struct {
+	// Frequency is the probability that a request is answered with a fault
+	Frequency float64
+	// Kinds are the faults to pick from, with equal probability
+	Kinds []FaultKind
+}
+
+func (f FaultConfig) Validate() error {
+	if f.Frequency < 0 {
+		return fieldError("Frequency", fmt.Errorf("frequency cannot be negative"))
+	}
+
+	if f.Frequency > 1 {
+		return fieldError("Frequency", fmt.Errorf("frequency cannot be greater than 1"))
+	}
+
+	if len(f.Kinds) == 0 {
+		return fieldError("Kinds", fmt.Errorf("kinds cannot be empty"))
+	}
+
+	for i, kind := range f.Kinds {
+		if !isFaultKind(kind) {
+			return fieldError(fmt.Sprintf("Kinds[%d]", i), fmt.Errorf("invalid fault kind %q", kind))
+		}
+	}
+
+	return nil
+}
+
+func isFaultKind(kind FaultKind) bool {
+	for _, k := range faultKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func shouldTriggerFault(rng *rand.Rand, faultCfg *FaultConfig) bool {
+	if faultCfg == nil {
+		return false
+	}
+	return rng.Float64() < faultCfg.Frequency
+}
+
+// randomFault picks one of the fault kinds of faultCfg
+func randomFault(rng *rand.Rand, faultCfg *FaultConfig) FaultKind {
+	return faultCfg.Kinds[rng.Intn(len(faultCfg.Kinds))]
+}
+
+// injectFault answers r with a fault of the given kind
+func injectFault(w http.ResponseWriter, r *http.Request, e *endpoint, kind FaultKind) {
+	switch kind {
+	case FaultCloseConnection:
+		closeConnection(w, false)
+	case FaultConnectionReset:
+		closeConnection(w, true)
+	case FaultHang:
+		<-r.Context().Done()
+	case FaultHeadersThenClose, FaultTruncatedBody:
+		buf := newResponseBuffer()
+		e.respond(buf, r, e)
+		// the client sees the status code even though the response is cut short
+		recordFromContext(r.Context()).StatusCode = buf.status
+
+		n := 0
+		if kind == FaultTruncatedBody {
+			n = buf.body.Len() / 2
+		}
+		writePartialResponse(w, buf, n)
+	case FaultMalformedJSON:
+		buf := newResponseBuffer()
+		e.respond(buf, r, e)
+		// a prefix of a JSON document followed by an opening brace is never valid JSON
+		body := bytes.TrimSpace(buf.body.Bytes())
+		body = append(append([]byte(nil), body[:len(body)/2]...), '{')
+
+		copyHeader(w.Header(), buf.header)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(buf.status)
+		w.Write(body)
+	}
+}
+
+// closeConnection closes the connection of w without a response, with a TCP RST if reset is set.
+// Connections that cannot be hijacked, e.g. HTTP/2 streams, are aborted with http.ErrAbortHandler.
+func closeConnection(w http.ResponseWriter, reset bool) {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+
+	if reset {
+		setLingerZero(conn)
+	}
+	conn.Close()
+}
+
+// setLingerZero makes closing conn discard unsent data and send a RST instead of a FIN
+func setLingerZero(conn net.Conn) {
+	if c, ok := conn.(interface{ NetConn() net.Conn }); ok {
+		conn = c.NetConn()
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		tcpConn.SetLinger(0)
+	}
+}
+
+// writePartialResponse sends the status line and headers of buf, declaring the full body length,
+// followed by the first n bytes of the body, then closes the connection
+func writePartialResponse(w http.ResponseWriter, buf *responseBuffer, n int) {
+	body := buf.body.Bytes()
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		copyHeader(w.Header(), buf.header)
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(buf.status)
+		w.Write(body[:n])
+		http.NewResponseController(w).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	defer conn.Close()
+
+	header := buf.header.Clone()
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	header.Set("Connection", "close")
+	writeRawResponse(rw.Writer, buf.status, header, body[:n])
+}
+
+// writeRawResponse writes an HTTP/1.1 response to a hijacked connection
+func writeRawResponse(bw *bufio.Writer, statusCode int, header http.Header, body []byte) {
+	fmt.Fprintf(bw, "HTTP/1.1 %d %s\r\n", statusCode, http.StatusText(statusCode))
+	header.Write(bw)
+	bw.WriteString("\r\n")
+	bw.Write(body)
+	bw.Flush()
+}
+
+// responseBuffer is an http.ResponseWriter keeping the response in memory
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{header: http.Header{}, status: http.StatusOK}
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(statusCode int) {
+	b.status = statusCode
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+func copyHeader(dst, src http.Header) {
+	for key, values := range src {
+		dst[key] = append([]string(nil), values...)
+	}
+}
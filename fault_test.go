@@ -0,0 +1,169 @@
+package fauxmux
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFaultConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  FaultConfig
+		wantErr bool
+	}{
+		{name: "valid", config: FaultConfig{Frequency: 0.5, Kinds: []FaultKind{FaultHang, FaultConnectionReset}}},
+		{name: "negative frequency", config: FaultConfig{Frequency: -0.1, Kinds: []FaultKind{FaultHang}}, wantErr: true},
+		{name: "frequency greater than 1", config: FaultConfig{Frequency: 1.1, Kinds: []FaultKind{FaultHang}}, wantErr: true},
+		{name: "no kinds", config: FaultConfig{Frequency: 0.5}, wantErr: true},
+		{name: "invalid kind", config: FaultConfig{Frequency: 0.5, Kinds: []FaultKind{"explode"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// newFaultServer serves an endpoint always answering with the given fault
+func newFaultServer(t *testing.T, kind FaultKind) (*Mux, *httptest.Server) {
+	t.Helper()
+	mux := NewMux()
+
+	err := RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/users",
+		ResponseFormat: JSON,
+		FaultConfig:    &FaultConfig{Frequency: 1, Kinds: []FaultKind{kind}},
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	server := httptest.NewServer(mux.Mux())
+	t.Cleanup(server.Close)
+	return mux, server
+}
+
+// assertFaultRecorded waits for the journal to record the fault, as the handler may still be
+// running when the client observes it
+func assertFaultRecorded(t *testing.T, mux *Mux, kind FaultKind) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		requests := mux.Journal().Find("GET", "/users", WithOutcome(OutcomeFault))
+		if len(requests) == 1 {
+			if requests[0].Fault != kind {
+				t.Errorf("expected fault %q to be recorded but got %q", kind, requests[0].Fault)
+			}
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Errorf("expected fault %q to be recorded", kind)
+}
+
+func TestFaults_ConnectionClosed(t *testing.T) {
+	for _, kind := range []FaultKind{FaultCloseConnection, FaultConnectionReset} {
+		t.Run(string(kind), func(t *testing.T) {
+			mux, server := newFaultServer(t, kind)
+
+			client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+			resp, err := client.Get(server.URL + "/users")
+			if err == nil {
+				resp.Body.Close()
+				t.Fatalf("expected the connection to fail but got status code %d", resp.StatusCode)
+			}
+			assertFaultRecorded(t, mux, kind)
+		})
+	}
+}
+
+func TestFaults_Hang(t *testing.T) {
+	mux, server := newFaultServer(t, FaultHang)
+
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	resp, err := client.Get(server.URL + "/users")
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("expected the request to time out but got status code %d", resp.StatusCode)
+	}
+	assertFaultRecorded(t, mux, FaultHang)
+}
+
+func TestFaults_PartialResponse(t *testing.T) {
+	tests := []struct {
+		kind     FaultKind
+		wantBody bool
+	}{
+		{kind: FaultHeadersThenClose, wantBody: false},
+		{kind: FaultTruncatedBody, wantBody: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			mux, server := newFaultServer(t, tt.kind)
+
+			resp, err := http.Get(server.URL + "/users")
+			if err != nil {
+				t.Fatalf("expected headers to be received but got %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("expected status code %d but got %d", http.StatusOK, resp.StatusCode)
+			}
+			if resp.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("expected JSON content type but got %q", resp.Header.Get("Content-Type"))
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("expected unexpected EOF reading the body but got %v", err)
+			}
+			if int64(len(body)) >= resp.ContentLength {
+				t.Errorf("expected body shorter than %d bytes but got %d", resp.ContentLength, len(body))
+			}
+			if (len(body) > 0) != tt.wantBody {
+				t.Errorf("expected partial body %v but got %q", tt.wantBody, body)
+			}
+			assertFaultRecorded(t, mux, tt.kind)
+		})
+	}
+}
+
+func TestFaults_MalformedJSON(t *testing.T) {
+	mux := NewMux()
+
+	err := RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/users",
+		ResponseFormat: JSON,
+		FaultConfig:    &FaultConfig{Frequency: 1, Kinds: []FaultKind{FaultMalformedJSON}},
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status code %d but got %d", http.StatusOK, w.Code)
+	}
+	if w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected JSON content type but got %q", w.Header().Get("Content-Type"))
+	}
+	var user User
+	if err := json.Unmarshal(w.Body.Bytes(), &user); err == nil {
+		t.Errorf("expected malformed JSON but got %q", w.Body.String())
+	}
+	mux.Journal().AssertCalled(t, "GET", "/users", 1, WithOutcome(OutcomeFault), WithStatusCode(http.StatusOK))
+}
@@ -1,98 +1,634 @@
 package fauxmux
 
 import (
+	"cmp"
 	"fmt"
+	"log/slog"
 	"math/rand"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 )
 
 type Mux struct {
-	mux    *http.ServeMux
-	routes sync.Map
+	mux *http.ServeMux
+	// routes maps every registered path to a sync.Map of its methods' endpoints, a []*endpoint
+	// holding one endpoint per scenario state. mu serializes their registration.
+	routes    sync.Map
+	mu        sync.Mutex
+	scenarios scenarios
+	seed      int64
+	rand      *rand.Rand
+	journal   *Journal
+	// rateLimiter, when set, limits the requests to every endpoint
+	rateLimiter *rateLimiter
+	// resetters restore the state of the resources of the Mux
+	resetters []func()
+	// adminPrefix, when set, is the path prefix the admin API is mounted under
+	adminPrefix string
+	// fakeData, defaultLatency and defaultErrors, when set, apply to the endpoints that don't set their own
+	fakeData       FakeDataFunc
+	defaultLatency LatencyModel
+	defaultErrors  *ErrorResponseConfig
+	// logger, when set, logs every request recorded in the journal
+	logger *slog.Logger
+
+	latencyMultiplier float64
 }
 
 // NewMux creates a new Mux instance
-func NewMux() *Mux {
-	return &Mux{
-		mux:    http.NewServeMux(),
-		routes: sync.Map{},
+func NewMux(opts ...Option) *Mux {
+	fm := &Mux{
+		mux:     http.NewServeMux(),
+		routes:  sync.Map{},
+		seed:    time.Now().UnixNano(),
+		journal: &Journal{},
+
+		latencyMultiplier: 1,
+	}
+	for _, opt := range opts {
+		opt(fm)
 	}
+	fm.rand = newRand(fm.seed)
+	if fm.adminPrefix != "" {
+		fm.mux.Handle(fm.adminPrefix+"/", http.StripPrefix(fm.adminPrefix, fm.AdminHandler()))
+	}
+	return fm
 }
 
-// Mux returns the underlying http.ServeMux of the Mux
+// Mux returns the underlying http.ServeMux of the Mux. Requests it serves directly are recorded in
+// the journal only if they match a registered path; serve the Mux itself to record the others too.
 func (fm *Mux) Mux() *http.ServeMux {
 	return fm.mux
 }
 
-// Routes returns a list of registered routes in the format "METHOD PATH"
-func (fm *Mux) Routes() []string {
-	paths := make([]string, 0)
-	fm.routes.Range(func(path, methods any) bool {
-		methods.(*sync.Map).Range(func(method, _ any) bool {
-			paths = append(paths, fmt.Sprintf("%s %s", method, path))
+// ServeHTTP serves r with the underlying http.ServeMux, recording the requests that match none of
+// its patterns with OutcomeNoRoute so that the journal shows calls to wrong URLs
+func (fm *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h, pattern := fm.mux.Handler(r)
+	if pattern != "" {
+		fm.mux.ServeHTTP(w, r)
+		return
+	}
+
+	fm.recordRequest(w, r, "", func(w http.ResponseWriter, r *http.Request) {
+		recordFromContext(r.Context()).Outcome = OutcomeNoRoute
+		h.ServeHTTP(w, r)
+	})
+}
+
+// Seed returns the seed of the random source used by the Mux
+func (fm *Mux) Seed() int64 {
+	return fm.seed
+}
+
+// Journal returns the journal recording every request served by the Mux, except those of the admin API
+func (fm *Mux) Journal() *Journal {
+	return fm.journal
+}
+
+// Routes describes every registered endpoint, sorted by path, method and scenario state
+func (fm *Mux) Routes() []Route {
+	routes := make([]Route, 0)
+	fm.rangeEndpoints(func(e *endpoint) {
+		routes = append(routes, e.route())
+	})
+	slices.SortFunc(routes, func(a, b Route) int {
+		return cmp.Or(
+			cmp.Compare(a.Path, b.Path),
+			cmp.Compare(a.Method, b.Method),
+			cmp.Compare(a.Scenario, b.Scenario),
+			cmp.Compare(a.RequiredState, b.RequiredState),
+		)
+	})
+	return routes
+}
+
+// Route describes an endpoint registered on a Mux
+type Route struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Scenario and RequiredState are those of the endpoint's ScenarioConfig, if any
+	Scenario      string `json:"scenario,omitempty"`
+	RequiredState string `json:"required_state,omitempty"`
+	// Latency describes the latency distribution of the endpoint
+	Latency         string           `json:"latency"`
+	ResponseFormats []ResponseFormat `json:"response_formats"`
+	List            bool             `json:"list"`
+	ErrorFrequency  float64          `json:"error_frequency"`
+	FaultFrequency  float64          `json:"fault_frequency"`
+	Scripted        bool             `json:"scripted"`
+	RateLimited     bool             `json:"rate_limited"`
+	Throttled       bool             `json:"throttled"`
+}
+
+// route describes the endpoint
+func (e *endpoint) route() Route {
+	route := Route{
+		Method:          e.cfg.Method,
+		Path:            e.cfg.Path,
+		Latency:         fmt.Sprint(e.cfg.latencyModel()),
+		ResponseFormats: e.cfg.responseFormats(),
+		List:            e.cfg.ListResponseConfig != nil,
+		Scripted:        e.cfg.Script != nil,
+		RateLimited:     e.cfg.RateLimit != nil,
+		Throttled:       e.cfg.Throttle != nil,
+	}
+	if e.cfg.Scenario != nil {
+		route.Scenario = e.cfg.Scenario.Name
+		route.RequiredState = e.cfg.Scenario.RequiredState
+	}
+	if e.cfg.ErrorResponseConfig != nil {
+		route.ErrorFrequency = e.cfg.ErrorResponseConfig.Frequency
+	}
+	if e.cfg.FaultConfig != nil {
+		route.FaultFrequency = e.cfg.FaultConfig.Frequency
+	}
+	return route
+}
+
+// ResetScripts restarts the script of every endpoint from its first step
+func (fm *Mux) ResetScripts() {
+	fm.rangeEndpoints(func(e *endpoint) {
+		if e.script != nil {
+			e.script.reset()
+		}
+	})
+}
+
+// Reset restores the initial state of the Mux: it discards the journal, restarts every script and
+// scenario, forgets the requests counted by rate limits and templates and restores the seeded items
+// of resources
+func (fm *Mux) Reset() {
+	fm.journal.Reset()
+	fm.ResetScripts()
+	fm.ResetScenarios()
+	if fm.rateLimiter != nil {
+		fm.rateLimiter.reset()
+	}
+	fm.rangeEndpoints(func(e *endpoint) {
+		if e.rateLimiter != nil {
+			e.rateLimiter.reset()
+		}
+		if e.template != nil {
+			e.template.count.Store(0)
+		}
+	})
+
+	fm.mu.Lock()
+	resetters := fm.resetters
+	fm.mu.Unlock()
+	for _, reset := range resetters {
+		reset()
+	}
+}
+
+// rangeEndpoints calls fn for every registered endpoint
+func (fm *Mux) rangeEndpoints(fn func(e *endpoint)) {
+	fm.routes.Range(func(_, methods any) bool {
+		methods.(*sync.Map).Range(func(_, endpoints any) bool {
+			for _, e := range endpoints.([]*endpoint) {
+				fn(e)
+			}
 			return true
 		})
 		return true
 	})
-	return paths
 }
 
-// RegisterEndpoint registers a new endpoint with a specific configuration for a given response type
+// RegisterEndpoint registers a new endpoint with a specific configuration for a given response type,
+// replacing any endpoint registered for the same method, path and scenario state
 func RegisterEndpoint[T any](fm *Mux, endpointCfg EndpointConfig) error {
 	if err := endpointCfg.Validate(); err != nil {
 		return fmt.Errorf("failed to register endpoint: %v", err)
 	}
 
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		latency := time.Duration(rand.Intn(int(endpointCfg.MaxLatency-endpointCfg.MinLatency))) + endpointCfg.MinLatency
-		time.Sleep(latency)
+	return fm.register(endpointCfg, respondWithFakeData[T])
+}
 
-		if shouldTriggerError(endpointCfg.ErrorResponseConfig) {
-			handleErrorResponse(w, endpointCfg)
-			return
+// ReplaceEndpoint replaces the endpoint registered for the method, path and scenario state of
+// endpointCfg, failing with ErrEndpointNotFound if there is none. Requests being served when it is
+// replaced are answered by the previous endpoint.
+func ReplaceEndpoint[T any](fm *Mux, endpointCfg EndpointConfig) error {
+	if err := endpointCfg.Validate(); err != nil {
+		return fmt.Errorf("failed to replace endpoint: %v", err)
+	}
+
+	if err := fm.replace(endpointCfg, respondWithFakeData[T]); err != nil {
+		return fmt.Errorf("failed to replace endpoint: %w", err)
+	}
+	return nil
+}
+
+// UnregisterEndpoint removes the endpoints of every scenario state registered for method and path,
+// failing with ErrEndpointNotFound if there are none. Requests being served when it is removed are
+// still answered, later ones receive 405 Method Not Allowed, or 404 Not Found if no method of the
+// path is left.
+func (fm *Mux) UnregisterEndpoint(method, path string) error {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	if methodEndpoints, ok := fm.routes.Load(path); ok {
+		if _, ok := methodEndpoints.(*sync.Map).LoadAndDelete(method); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to unregister endpoint: %w", ErrEndpointNotFound)
+}
+
+// negotiateFormat returns the format of the successful response to r. If the endpoint cannot respond
+// in any format acceptable to r, it is answered with 406 Not Acceptable and negotiateFormat returns false.
+func (e *endpoint) negotiateFormat(w http.ResponseWriter, r *http.Request) (ResponseFormat, bool) {
+	if len(e.cfg.ResponseFormats) == 0 {
+		return e.cfg.ResponseFormat, true
+	}
+
+	w.Header().Add("Vary", "Accept")
+	format, ok := negotiateFormat(r.Header.Get("Accept"), e.cfg.responseFormats())
+	if !ok {
+		http.Error(w, "Not Acceptable", http.StatusNotAcceptable)
+	}
+	return format, ok
+}
+
+// respondWithFakeData writes a successful response holding a faked T, or a list of them, in the
+// shape of one of the endpoint's variants
+func respondWithFakeData[T any](w http.ResponseWriter, r *http.Request, e *endpoint) {
+	format, ok := e.negotiateFormat(w, r)
+	if !ok {
+		return
+	}
+
+	variant := e.pickVariant()
+	if variant != nil {
+		if record := recordFromContext(r.Context()); record != nil {
+			record.Variant = variant.Name
 		}
+	}
 
-		var response interface{}
-		var err error
-		if endpointCfg.ListResponseConfig != nil {
-			response, err = getListResponseData[T](endpointCfg)
-		} else {
-			response, err = getResponseData[T](endpointCfg)
+	var response interface{}
+	var err error
+	switch {
+	case variant != nil && variant.Response != nil:
+		response = variant.Response
+	case e.cfg.ListResponseConfig != nil:
+		response, err = getListResponseData[T](e.rng, e.cfg.ListResponseConfig, e.fm.fakeDataFunc(e.cfg, e.rng))
+	default:
+		response, err = getResponseData[T](e.fm.fakeDataFunc(e.cfg, e.rng))
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal Server Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// a variant's response is shared by every request, so it is written as is
+	static := variant != nil && variant.Response != nil
+	if e.cfg.BindPathParams && !static {
+		if err := bindPathParams(response, pathParams(r, e.paramNames)); err != nil {
+			http.Error(w, fmt.Sprintf("Bad Request: %v", err), http.StatusBadRequest)
+			return
 		}
+	}
+
+	hooks := make([]ResponseHook, 0, 2)
+	if variant != nil && variant.Hook != nil {
+		hooks = append(hooks, variant.Hook)
+	}
+	if e.cfg.ResponseHook != nil && !static {
+		hooks = append(hooks, e.cfg.ResponseHook)
+	}
+	if len(hooks) > 0 {
+		req, err := newRequest(r, e.paramNames)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("Internal Server Error: %v", err), http.StatusInternalServerError)
+			http.Error(w, fmt.Sprintf("Bad Request: %v", err), http.StatusBadRequest)
 			return
 		}
+		for _, hook := range hooks {
+			response, err = hook(req, response)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Internal Server Error: %v", err), http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	writeResponse(w, http.StatusOK, format, response)
+}
+
+// endpoint is a registered endpoint
+type endpoint struct {
+	fm          *Mux
+	cfg         EndpointConfig
+	rng         *rand.Rand
+	paramNames  []string
+	rateLimiter *rateLimiter
+	script      *script
+	template    *responseTemplate
+	// respond writes a successful response once latency and errors have been simulated
+	respond func(w http.ResponseWriter, r *http.Request, e *endpoint)
+}
 
-		switch ResponseFormat(endpointCfg.ResponseFormat) {
-		case JSON:
-			writeJSON(w, response)
-		default:
-			http.Error(w, "Invalid Response Format", http.StatusInternalServerError)
+// register registers a validated endpoint whose successful responses are written by respond
+func (fm *Mux) register(endpointCfg EndpointConfig, respond func(w http.ResponseWriter, r *http.Request, e *endpoint)) error {
+	return fm.store(fm.newEndpoint(endpointCfg, respond), false)
+}
+
+// replace replaces the endpoint registered for the method, path and scenario state of a validated
+// endpoint configuration
+func (fm *Mux) replace(endpointCfg EndpointConfig, respond func(w http.ResponseWriter, r *http.Request, e *endpoint)) error {
+	return fm.store(fm.newEndpoint(endpointCfg, respond), true)
+}
+
+func (fm *Mux) newEndpoint(endpointCfg EndpointConfig, respond func(w http.ResponseWriter, r *http.Request, e *endpoint)) *endpoint {
+	if endpointCfg.Latency == nil && endpointCfg.MinLatency == 0 && endpointCfg.MaxLatency == 0 {
+		endpointCfg.Latency = fm.defaultLatency
+	}
+	if endpointCfg.ErrorResponseConfig == nil {
+		endpointCfg.ErrorResponseConfig = fm.defaultErrors
+	}
+
+	e := &endpoint{
+		fm:      fm,
+		cfg:     endpointCfg,
+		rng:     fm.rand,
+		respond: respond,
+	}
+	if endpointCfg.Seed != nil {
+		e.rng = newRand(*endpointCfg.Seed)
+	}
+	if endpointCfg.RateLimit != nil {
+		e.rateLimiter = newRateLimiter(*endpointCfg.RateLimit)
+	}
+	if endpointCfg.Script != nil {
+		e.script = &script{cfg: *endpointCfg.Script}
+	}
+	if endpointCfg.Template != "" {
+		// Validate has already parsed the template
+		tmpl, _ := parseTemplate(endpointCfg.Template, e.rng)
+		e.template = &responseTemplate{tmpl: tmpl}
+		e.respond = respondWithTemplate
+	}
+	if endpointCfg.Fixture != nil {
+		e.respond = respondWithFixture
+	}
+	// Validate has already checked the pattern
+	e.paramNames, _ = pathParamNames(endpointCfg.Path)
+	return e
+}
+
+// store adds e to the endpoints of its method and path, in place of the one of the same scenario
+// state. If replace is set, there must be one. Paths are handled by the http.ServeMux, which cannot
+// forget a pattern, from their first registration on, even once their endpoints are unregistered.
+// The paths under the prefix of the admin API are reserved for it.
+func (fm *Mux) store(e *endpoint, replace bool) error {
+	if fm.reserved(e.cfg.Path) {
+		return fmt.Errorf("failed to register endpoint: path %s is reserved for the admin API", e.cfg.Path)
+	}
+
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	if replace {
+		if _, ok := fm.routes.Load(e.cfg.Path); !ok {
+			return ErrEndpointNotFound
+		}
+	}
+
+	methodEndpoints, loaded := fm.routes.LoadOrStore(e.cfg.Path, &sync.Map{})
+	var endpoints []*endpoint
+	replaced := false
+	if current, ok := methodEndpoints.(*sync.Map).Load(e.cfg.Method); ok {
+		// requests being served keep reading the slice they loaded, so it is copied
+		for _, other := range current.([]*endpoint) {
+			if scenarioKey(other.cfg.Scenario) == scenarioKey(e.cfg.Scenario) {
+				replaced = true
+				continue
+			}
+			endpoints = append(endpoints, other)
+		}
+	}
+	if replace && !replaced {
+		return ErrEndpointNotFound
+	}
+	methodEndpoints.(*sync.Map).Store(e.cfg.Method, append(endpoints, e))
+
+	if !loaded {
+		if err := fm.handlePath(e.cfg.Path); err != nil {
+			fm.routes.Delete(e.cfg.Path)
+			return fmt.Errorf("failed to register endpoint: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// updateEndpoints replaces the endpoints of every scenario state registered for method and path
+// with copies whose configuration has been changed by update. Requests being served finish with
+// the configuration they started with.
+func (fm *Mux) updateEndpoints(method, path string, update func(cfg *EndpointConfig) error) error {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	methodEndpoints, ok := fm.routes.Load(path)
+	if !ok {
+		return ErrEndpointNotFound
+	}
+	current, ok := methodEndpoints.(*sync.Map).Load(method)
+	if !ok {
+		return ErrEndpointNotFound
+	}
+
+	endpoints := make([]*endpoint, 0, len(current.([]*endpoint)))
+	for _, e := range current.([]*endpoint) {
+		updated := *e
+		if err := update(&updated.cfg); err != nil {
+			return err
+		}
+		if err := updated.cfg.Validate(); err != nil {
+			return err
+		}
+		endpoints = append(endpoints, &updated)
+	}
+	methodEndpoints.(*sync.Map).Store(method, endpoints)
+	return nil
+}
+
+// serveEndpoint enforces rate limits and simulates latency, faults and errors, scripted or random,
+// before letting the endpoint respond. A request cancelled by its client while waiting out the
+// latency is abandoned without a response.
+func (fm *Mux) serveEndpoint(w http.ResponseWriter, r *http.Request, e *endpoint) {
+	record := recordFromContext(r.Context())
+
+	// rate limited requests are rejected straight away, like real rate limiters do
+	for _, limiter := range []*rateLimiter{fm.rateLimiter, e.rateLimiter} {
+		if limiter != nil && !limiter.limit(w, r) {
+			record.Outcome = OutcomeRateLimited
 			return
 		}
+	}
+
+	var step ScriptStep
+	scripted := false
+	if e.script != nil {
+		step, scripted = e.script.step()
+	}
+
+	latencyModel := e.cfg.latencyModel()
+	if step.Latency != nil {
+		latencyModel = step.Latency
+	}
+	latency := time.Duration(float64(latencyModel.Sample(e.rng)) * fm.latencyMultiplier)
+	record.Latency = latency
+
+	if err := sleepContext(r.Context(), latency); err != nil {
+		// nobody is left to read the response
+		record.Outcome = OutcomeCancelled
+		return
+	}
+
+	if step.Fault != "" || !scripted && shouldTriggerFault(e.rng, e.cfg.FaultConfig) {
+		kind := step.Fault
+		if kind == "" {
+			kind = randomFault(e.rng, e.cfg.FaultConfig)
+		}
+		record.Outcome = OutcomeFault
+		record.Fault = kind
+		injectFault(w, r, e, kind)
+		return
+	}
+
+	if e.cfg.BodyLatency != nil {
+		record.BodyLatency = time.Duration(float64(e.cfg.BodyLatency.Sample(e.rng)) * fm.latencyMultiplier)
+	}
+
+	if e.cfg.Throttle != nil || record.BodyLatency > 0 {
+		slow := &slowWriter{
+			ResponseWriter: w,
+			ctx:            r.Context(),
+			record:         record,
+			bodyDelay:      record.BodyLatency,
+			throttle:       e.cfg.Throttle,
+			multiplier:     fm.latencyMultiplier,
+		}
+		defer slow.finish()
+		w = slow
+	}
+
+	if step.Error != nil {
+		record.Outcome = OutcomeError
+		record.ErrorResponse = step.Error
+		writeErrorResponse(w, *step.Error)
+		return
+	}
+
+	if !scripted && shouldTriggerError(e.rng, e.cfg.ErrorResponseConfig) {
+		record.Outcome = OutcomeError
+		record.ErrorResponse = handleErrorResponse(w, e.rng, e.cfg)
+		return
+	}
+
+	record.Outcome = OutcomeResponse
+	fm.scenarios.advance(e)
+	e.respond(w, r, e)
+}
+
+// handlePath registers path on the underlying http.ServeMux, dispatching requests to the
+// handler registered for their method. http.ServeMux panics on invalid or conflicting
+// patterns, e.g. "/users/{id}" and "/users/{name}"; the panic is returned as an error.
+func (fm *Mux) handlePath(path string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	fm.mux.HandleFunc(path, fm.servePath(path))
+	return nil
+}
+
+// reserved reports whether path is under the prefix of the admin API
+func (fm *Mux) reserved(path string) bool {
+	return fm.adminPrefix != "" && (path == fm.adminPrefix || strings.HasPrefix(path, fm.adminPrefix+"/"))
+}
+
+// checkPaths reports the first of paths that cannot be registered alongside the registered paths
+// and those before it, with its index, without registering any
+func (fm *Mux) checkPaths(paths []string) (index int, err error) {
+	scratch := http.NewServeMux()
+	handle := func(path string) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("%v", r)
+			}
+		}()
+		scratch.Handle(path, http.NotFoundHandler())
+		return nil
+	}
+
+	if fm.adminPrefix != "" {
+		handle(fm.adminPrefix + "/")
+	}
+	seen := map[string]bool{}
+	fm.routes.Range(func(path, _ any) bool {
+		seen[path.(string)] = true
+		// registered paths don't conflict with each other
+		handle(path.(string))
+		return true
 	})
 
-	methodHandlers, loaded := fm.routes.LoadOrStore(endpointCfg.Path, &sync.Map{})
-	methodHandlers.(*sync.Map).Store(endpointCfg.Method, handler)
+	for i, path := range paths {
+		if fm.reserved(path) {
+			return i, fmt.Errorf("path %s is reserved for the admin API", path)
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		if err := handle(path); err != nil {
+			return i, err
+		}
+	}
+	return 0, nil
+}
 
-	if !loaded {
-		fm.mux.HandleFunc(endpointCfg.Path, func(w http.ResponseWriter, r *http.Request) {
-			methodHandlers, ok := fm.routes.Load(endpointCfg.Path)
-			if !ok {
+// servePath returns the handler of the registered path, dispatching requests to the endpoints of their method
+func (fm *Mux) servePath(path string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fm.recordRequest(w, r, path, func(w http.ResponseWriter, r *http.Request) {
+			methodEndpoints, ok := fm.routes.Load(path)
+			if !ok || !hasEntries(methodEndpoints.(*sync.Map)) {
+				// every endpoint of the path has been unregistered
+				recordFromContext(r.Context()).Outcome = OutcomeNoRoute
 				http.Error(w, "Not Found", http.StatusNotFound)
 				return
 			}
 
-			if methodHandler, ok := methodHandlers.(*sync.Map).Load(r.Method); ok {
-				methodHandler.(http.HandlerFunc).ServeHTTP(w, r)
-			} else {
+			endpoints, ok := methodEndpoints.(*sync.Map).Load(r.Method)
+			if !ok {
+				recordFromContext(r.Context()).Outcome = OutcomeNoRoute
 				http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+				return
+			}
+
+			e, ok := fm.scenarios.match(endpoints.([]*endpoint))
+			if !ok {
+				// no endpoint answers in the current state of the scenario
+				recordFromContext(r.Context()).Outcome = OutcomeNoRoute
+				http.Error(w, "Not Found", http.StatusNotFound)
+				return
 			}
+			fm.serveEndpoint(w, r, e)
 		})
 	}
+}
 
-	return nil
+func hasEntries(m *sync.Map) bool {
+	found := false
+	m.Range(func(_, _ any) bool {
+		found = true
+		return false
+	})
+	return found
 }
@@ -1,11 +1,17 @@
 package fauxmux
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -328,3 +334,345 @@ func TestFauxMuxMultipleMethodsSamePath(t *testing.T) {
 		t.Fatalf("unexpected status code for PUT: got %v, want %v", resp.StatusCode, http.StatusMethodNotAllowed)
 	}
 }
+
+// TestFauxMuxSeedDeterminism tests that muxes with the same seed produce the same responses
+func TestFauxMuxSeedDeterminism(t *testing.T) {
+	endpointCfg := EndpointConfig{
+		Method:         "GET",
+		Path:           "/users",
+		MinLatency:     0,
+		MaxLatency:     time.Millisecond,
+		ResponseFormat: JSON,
+		ListResponseConfig: &ListResponseConfig{
+			MinItems: 0,
+			MaxItems: 20,
+		},
+		ErrorResponseConfig: &ErrorResponseConfig{
+			Frequency: 0.3,
+			Responses: []ErrorResponse{
+				{StatusCode: 500, Response: Error{Message: "Internal"}, ResponseFormat: JSON},
+				{StatusCode: 503, Response: Error{Message: "Unavailable"}, ResponseFormat: JSON},
+				{StatusCode: 404, Response: Error{Message: "Not Found"}, ResponseFormat: JSON},
+			},
+		},
+	}
+
+	run := func(mux *Mux) []string {
+		if err := RegisterEndpoint[User](mux, endpointCfg); err != nil {
+			t.Fatalf("failed to register endpoint: %v", err)
+		}
+		results := make([]string, 0, 30)
+		for i := 0; i < 30; i++ {
+			req := httptest.NewRequest("GET", "/users", nil)
+			w := httptest.NewRecorder()
+			mux.Mux().ServeHTTP(w, req)
+			results = append(results, fmt.Sprintf("%d %s", w.Code, w.Body.String()))
+		}
+		return results
+	}
+
+	first := NewMux(WithSeed(42))
+	second := NewMux(WithSeed(42))
+	if first.Seed() != 42 || second.Seed() != 42 {
+		t.Fatalf("expected seed 42 but got %d and %d", first.Seed(), second.Seed())
+	}
+
+	if got, want := run(second), run(first); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected identical responses for identical seeds\ngot:  %v\nwant: %v", got, want)
+	}
+
+	seed := int64(7)
+	endpointCfg.Seed = &seed
+	if got, want := run(NewMux(WithSeed(1))), run(NewMux(WithSeed(2))); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected identical responses for identical endpoint seeds\ngot:  %v\nwant: %v", got, want)
+	}
+}
+
+// TestFauxMuxLatencyMultiplier tests that injected latency is scaled by the Mux's multiplier
+func TestFauxMuxLatencyMultiplier(t *testing.T) {
+	mux := NewMux(WithLatencyMultiplier(0))
+
+	err := RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/users",
+		MinLatency:     500 * time.Millisecond,
+		MaxLatency:     1000 * time.Millisecond,
+		ResponseFormat: JSON,
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("expected latency to be disabled but got %v", elapsed)
+	}
+}
+
+// TestFauxMuxPathParams tests wildcard routes and binding captured values into the response
+func TestFauxMuxPathParams(t *testing.T) {
+	mux := NewMux()
+
+	err := RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/users/{id}",
+		ResponseFormat: JSON,
+		BindPathParams: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	err = RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/files/{name...}",
+		ResponseFormat: JSON,
+		BindPathParams: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/users/42", nil))
+	var user User
+	if err := json.Unmarshal(w.Body.Bytes(), &user); err != nil {
+		t.Fatalf("failed to unmarshal response body: %v", err)
+	}
+	if user.ID != 42 {
+		t.Fatalf("expected user 42 but got %+v", user)
+	}
+
+	w = httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/files/a/b/c.txt", nil))
+	if err := json.Unmarshal(w.Body.Bytes(), &user); err != nil {
+		t.Fatalf("failed to unmarshal response body: %v", err)
+	}
+	if user.Name != "a/b/c.txt" {
+		t.Fatalf("expected name a/b/c.txt but got %+v", user)
+	}
+
+	w = httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/users/abc", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status code %d but got %d", http.StatusBadRequest, w.Code)
+	}
+
+	err = RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/users/{name}",
+		ResponseFormat: JSON,
+	})
+	if err == nil {
+		t.Fatalf("expected conflicting pattern to fail registration")
+	}
+	if routes := mux.Routes(); len(routes) != 2 {
+		t.Fatalf("expected 2 routes but got %v", routes)
+	}
+}
+
+// TestFauxMuxClientCancellation tests that a request cancelled during the injected latency is abandoned
+func TestFauxMuxClientCancellation(t *testing.T) {
+	mux := NewMux()
+
+	generated := 0
+	err := RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/users",
+		Latency:        FixedLatency(10 * time.Second),
+		ResponseFormat: JSON,
+		FakeDataFunc: func(v interface{}) error {
+			generated++
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/users", nil).WithContext(ctx))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the handler to return once the request was cancelled but it took %v", elapsed)
+	}
+
+	if generated != 0 {
+		t.Errorf("expected no response to be generated but %d were", generated)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expected no response body but got %q", w.Body.String())
+	}
+	mux.Journal().AssertCalled(t, "GET", "/users", 1, WithOutcome(OutcomeCancelled))
+}
+
+// TestFauxMuxUnregisterEndpoint tests that unregistered endpoints stop answering and can be registered again
+func TestFauxMuxUnregisterEndpoint(t *testing.T) {
+	mux := NewMux()
+
+	for _, method := range []string{"GET", "DELETE"} {
+		err := RegisterEndpoint[User](mux, EndpointConfig{Method: method, Path: "/users/{id}", ResponseFormat: JSON})
+		if err != nil {
+			t.Fatalf("failed to register endpoint: %v", err)
+		}
+	}
+
+	serve := func(method string) int {
+		w := httptest.NewRecorder()
+		mux.Mux().ServeHTTP(w, httptest.NewRequest(method, "/users/1", nil))
+		return w.Code
+	}
+
+	if err := mux.UnregisterEndpoint("DELETE", "/users/{id}"); err != nil {
+		t.Fatalf("failed to unregister endpoint: %v", err)
+	}
+	if code := serve("DELETE"); code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status code %d but got %d", http.StatusMethodNotAllowed, code)
+	}
+
+	if err := mux.UnregisterEndpoint("GET", "/users/{id}"); err != nil {
+		t.Fatalf("failed to unregister endpoint: %v", err)
+	}
+	if code := serve("GET"); code != http.StatusNotFound {
+		t.Errorf("expected status code %d but got %d", http.StatusNotFound, code)
+	}
+	if err := mux.UnregisterEndpoint("GET", "/users/{id}"); !errors.Is(err, ErrEndpointNotFound) {
+		t.Errorf("expected ErrEndpointNotFound but got %v", err)
+	}
+	if len(mux.Routes()) != 0 {
+		t.Errorf("expected no routes but got %v", mux.Routes())
+	}
+
+	if err := RegisterEndpoint[User](mux, EndpointConfig{Method: "GET", Path: "/users/{id}", ResponseFormat: JSON}); err != nil {
+		t.Fatalf("failed to register endpoint again: %v", err)
+	}
+	if code := serve("GET"); code != http.StatusOK {
+		t.Errorf("expected status code %d but got %d", http.StatusOK, code)
+	}
+}
+
+// TestFauxMuxReplaceEndpoint tests that replacing an endpoint under concurrent traffic answers every request
+func TestFauxMuxReplaceEndpoint(t *testing.T) {
+	mux := NewMux()
+
+	if err := ReplaceEndpoint[User](mux, EndpointConfig{Method: "GET", Path: "/users", ResponseFormat: JSON}); !errors.Is(err, ErrEndpointNotFound) {
+		t.Fatalf("expected ErrEndpointNotFound but got %v", err)
+	}
+
+	if err := RegisterEndpoint[User](mux, EndpointConfig{Method: "GET", Path: "/users", ResponseFormat: JSON}); err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	codes := make(chan int, 400)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				w := httptest.NewRecorder()
+				mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+				codes <- w.Code
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		format := []ResponseFormat{JSON, XML}[i%2]
+		if err := ReplaceEndpoint[User](mux, EndpointConfig{Method: "GET", Path: "/users", ResponseFormat: format}); err != nil {
+			t.Fatalf("failed to replace endpoint: %v", err)
+		}
+	}
+	wg.Wait()
+	close(codes)
+
+	for code := range codes {
+		if code != http.StatusOK {
+			t.Fatalf("expected every request to be answered with %d but got %d", http.StatusOK, code)
+		}
+	}
+	if routes := mux.Routes(); len(routes) != 1 || routes[0].ResponseFormats[0] != XML {
+		t.Errorf("expected the last replacement to be registered but got %+v", routes)
+	}
+}
+
+// TestFauxMuxRoutes tests that routes are sorted and describe their endpoint
+func TestFauxMuxRoutes(t *testing.T) {
+	mux := NewMux()
+
+	endpointCfgs := []EndpointConfig{
+		{Method: "POST", Path: "/users", ResponseFormat: JSON},
+		{Method: "GET", Path: "/users/{id}", ResponseFormat: JSON, MaxLatency: time.Millisecond},
+		{Method: "GET", Path: "/users", ResponseFormat: JSON, ListResponseConfig: &ListResponseConfig{MaxItems: 2},
+			ErrorResponseConfig: &ErrorResponseConfig{Frequency: 0.25, Responses: []ErrorResponse{{StatusCode: 500, Response: "oops", ResponseFormat: Text}}}},
+	}
+	for _, endpointCfg := range endpointCfgs {
+		if err := RegisterEndpoint[User](mux, endpointCfg); err != nil {
+			t.Fatalf("failed to register endpoint: %v", err)
+		}
+	}
+
+	want := []Route{
+		{Method: "GET", Path: "/users", Latency: "uniform(min=0s, max=0s)", ResponseFormats: []ResponseFormat{JSON}, List: true, ErrorFrequency: 0.25},
+		{Method: "POST", Path: "/users", Latency: "uniform(min=0s, max=0s)", ResponseFormats: []ResponseFormat{JSON}},
+		{Method: "GET", Path: "/users/{id}", Latency: "uniform(min=0s, max=1ms)", ResponseFormats: []ResponseFormat{JSON}},
+	}
+	if routes := mux.Routes(); !reflect.DeepEqual(routes, want) {
+		t.Errorf("expected routes %+v but got %+v", want, routes)
+	}
+}
+
+// TestFauxMuxOptions tests that the fake data, defaults and logger of a Mux are scoped to it
+func TestFauxMuxOptions(t *testing.T) {
+	newMux := func(name string, opts ...Option) *Mux {
+		opts = append(opts, WithFakeDataFunc(func(v interface{}) error {
+			*v.(*User) = User{ID: 1, Name: name}
+			return nil
+		}))
+		mux := NewMux(opts...)
+		if err := RegisterEndpoint[User](mux, EndpointConfig{Method: "GET", Path: "/users/1", ResponseFormat: JSON}); err != nil {
+			t.Fatalf("failed to register endpoint: %v", err)
+		}
+		return mux
+	}
+
+	for _, name := range []string{"alice", "bob"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			mux := newMux(name)
+			for i := 0; i < 10; i++ {
+				w := httptest.NewRecorder()
+				mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+				var user User
+				if err := json.Unmarshal(w.Body.Bytes(), &user); err != nil || user.Name != name {
+					t.Fatalf("expected the fake data of the Mux but got %q", w.Body.String())
+				}
+			}
+		})
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		var logs strings.Builder
+		mux := newMux("carol",
+			WithDefaultLatency(FixedLatency(time.Millisecond)),
+			WithDefaultErrors(ErrorResponseConfig{Frequency: 1, Responses: []ErrorResponse{{StatusCode: 503, Response: "down", ResponseFormat: Text}}}),
+			WithLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+		)
+
+		w := httptest.NewRecorder()
+		mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected the default error response but got status code %d", w.Code)
+		}
+		if routes := mux.Routes(); len(routes) != 1 || routes[0].Latency != "fixed(1ms)" {
+			t.Errorf("expected the default latency but got %+v", routes)
+		}
+		if !strings.Contains(logs.String(), "outcome=error") || !strings.Contains(logs.String(), "status=503") {
+			t.Errorf("expected the request to be logged but got %q", logs.String())
+		}
+	})
+}
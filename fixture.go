@@ -0,0 +1,142 @@
+package fauxmux
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+// FixtureConfig answers successful requests with a recorded payload instead of a faked T. Exactly
+// one of Response, File and Dir must be set.
+type FixtureConfig struct {
+	// Response, when set, is the value written in the response format of the endpoint. It is shared
+	// by every response and must not be modified.
+	Response any
+	// File, when set, is the path of a file written byte for byte
+	File string
+	// Dir, when set, is the path of a directory holding a file per value of the path parameter
+	// Param, named after the value followed by Extension, written byte for byte. Requests for a
+	// value without a file are answered with 404 Not Found.
+	Dir       string
+	Param     string
+	Extension string
+	// ContentType, when set, replaces the content type of the response format for files
+	ContentType string
+}
+
+func (c FixtureConfig) Validate() error {
+	set := 0
+	for _, isSet := range []bool{c.Response != nil, c.File != "", c.Dir != ""} {
+		if isSet {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of response, file and dir must be set")
+	}
+
+	if c.File != "" {
+		if info, err := os.Stat(c.File); err != nil {
+			return fieldError("File", err)
+		} else if info.IsDir() {
+			return fieldError("File", fmt.Errorf("%s is a directory", c.File))
+		}
+	}
+
+	if c.Dir != "" {
+		if info, err := os.Stat(c.Dir); err != nil {
+			return fieldError("Dir", err)
+		} else if !info.IsDir() {
+			return fieldError("Dir", fmt.Errorf("%s is not a directory", c.Dir))
+		}
+		if c.Param == "" {
+			return fieldError("Param", fmt.Errorf("param cannot be empty when dir is set"))
+		}
+	}
+
+	return nil
+}
+
+// resolve returns the fixture with its relative file and directory paths made relative to dir
+func (c FixtureConfig) resolve(dir string) FixtureConfig {
+	if c.File != "" && !filepath.IsAbs(c.File) {
+		c.File = filepath.Join(dir, c.File)
+	}
+	if c.Dir != "" && !filepath.IsAbs(c.Dir) {
+		c.Dir = filepath.Join(dir, c.Dir)
+	}
+	return c
+}
+
+// validateFixture checks that the fixture of an endpoint can be combined with the rest of its configuration
+func (e EndpointConfig) validateFixture() error {
+	if e.ListResponseConfig != nil || e.BindPathParams || e.ResponseHook != nil || e.Template != "" {
+		return fmt.Errorf("fixture cannot be combined with list, bind path params, response hook or template")
+	}
+
+	if err := e.Fixture.Validate(); err != nil {
+		return err
+	}
+
+	if e.Fixture.Response == nil && len(e.ResponseFormats) > 0 {
+		return fieldError("File", fmt.Errorf("fixture files cannot be combined with response formats"))
+	}
+
+	if e.Fixture.Dir != "" {
+		// Validate has already checked the pattern
+		paramNames, _ := pathParamNames(e.Path)
+		if !slices.Contains(paramNames, e.Fixture.Param) {
+			return fieldError("Param", fmt.Errorf("path has no wildcard %q", e.Fixture.Param))
+		}
+	}
+
+	return nil
+}
+
+// respondWithFixture writes a successful response holding the endpoint's fixture
+func respondWithFixture(w http.ResponseWriter, r *http.Request, e *endpoint) {
+	fixture := e.cfg.Fixture
+	if fixture.Response != nil {
+		if format, ok := e.negotiateFormat(w, r); ok {
+			writeResponse(w, http.StatusOK, format, fixture.Response)
+		}
+		return
+	}
+
+	var body []byte
+	var err error
+	if fixture.File != "" {
+		body, err = os.ReadFile(fixture.File)
+	} else {
+		name := r.PathValue(fixture.Param) + fixture.Extension
+		if !fs.ValidPath(name) {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		body, err = fs.ReadFile(os.DirFS(fixture.Dir), name)
+		if errors.Is(err, fs.ErrNotExist) {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal Server Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	contentType := fixture.ContentType
+	if contentType == "" {
+		// Validate has already checked the format
+		enc, _ := lookupEncoder(e.cfg.ResponseFormat)
+		contentType = enc.ContentType()
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
@@ -0,0 +1,147 @@
+package fauxmux
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFixture(t *testing.T) {
+	dir := t.TempDir()
+	golden := []byte("{\"id\":  7,\n \"raw\": true}")
+	binary := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff}
+	if err := os.WriteFile(filepath.Join(dir, "order.json"), golden, 0o644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "images"), 0o755); err != nil {
+		t.Fatalf("failed to create fixture directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "images", "logo.png"), binary, 0o644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	mux := NewMux()
+	endpointCfgs := []EndpointConfig{
+		{
+			Method:          "GET",
+			Path:            "/status",
+			ResponseFormats: []ResponseFormat{JSON, XML},
+			Fixture:         &FixtureConfig{Response: map[string]string{"status": "ok"}},
+		},
+		{
+			Method:         "GET",
+			Path:           "/orders/7",
+			ResponseFormat: JSON,
+			Fixture:        &FixtureConfig{File: filepath.Join(dir, "order.json")},
+		},
+		{
+			Method:         "GET",
+			Path:           "/images/{name}",
+			ResponseFormat: Bytes,
+			Fixture:        &FixtureConfig{Dir: filepath.Join(dir, "images"), Param: "name", Extension: ".png", ContentType: "image/png"},
+		},
+	}
+	for _, endpointCfg := range endpointCfgs {
+		if err := RegisterEndpoint[any](mux, endpointCfg); err != nil {
+			t.Fatalf("failed to register endpoint: %v", err)
+		}
+	}
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	if w := serve("/status"); w.Body.String() != "{\"status\":\"ok\"}\n" {
+		t.Errorf("expected the static response but got %q", w.Body.String())
+	}
+
+	w := serve("/orders/7")
+	if !bytes.Equal(w.Body.Bytes(), golden) || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected the fixture file byte for byte but got %q %q", w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	w = serve("/images/logo")
+	if !bytes.Equal(w.Body.Bytes(), binary) || w.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("expected the fixture of the path parameter but got %q %q", w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	for _, path := range []string{"/images/missing", "/images/..%2forder"} {
+		if w := serve(path); w.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d for %s but got %d", http.StatusNotFound, path, w.Code)
+		}
+	}
+}
+
+func TestFixture_Validate(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "user.json")
+	if err := os.WriteFile(file, []byte("{}"), 0o644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		fixture FixtureConfig
+		wantErr bool
+	}{
+		{name: "file", path: "/users/1", fixture: FixtureConfig{File: file}},
+		{name: "dir", path: "/users/{id}", fixture: FixtureConfig{Dir: dir, Param: "id"}},
+		{name: "nothing", path: "/users/1", fixture: FixtureConfig{}, wantErr: true},
+		{name: "file and dir", path: "/users/{id}", fixture: FixtureConfig{File: file, Dir: dir, Param: "id"}, wantErr: true},
+		{name: "missing file", path: "/users/1", fixture: FixtureConfig{File: filepath.Join(dir, "missing.json")}, wantErr: true},
+		{name: "file is a directory", path: "/users/1", fixture: FixtureConfig{File: dir}, wantErr: true},
+		{name: "dir without param", path: "/users/{id}", fixture: FixtureConfig{Dir: dir}, wantErr: true},
+		{name: "param not in path", path: "/users/{id}", fixture: FixtureConfig{Dir: dir, Param: "name"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixture := tt.fixture
+			endpointCfg := EndpointConfig{Method: "GET", Path: tt.path, ResponseFormat: JSON, Fixture: &fixture}
+			if err := endpointCfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFixture_ConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "fixtures"), 0o755); err != nil {
+		t.Fatalf("failed to create fixture directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "fixtures", "42.json"), []byte(`{"id":42}`), 0o644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	config := `endpoints:
+  - method: GET
+    path: /users/{id}
+    response_format: json
+    fixture: {dir: fixtures, param: id, extension: .json}
+`
+	if err := os.WriteFile(filepath.Join(dir, "fauxmux.yaml"), []byte(config), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	mux := NewMux()
+	if err := LoadConfigFile(mux, filepath.Join(dir, "fauxmux.yaml")); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/users/42", nil))
+	if w.Body.String() != `{"id":42}` {
+		t.Errorf("expected the fixture relative to the config file but got %q", w.Body.String())
+	}
+
+	err := LoadConfig(NewMux(), strings.NewReader(strings.Replace(config, "fixtures", "missing", 1)))
+	if err == nil || !strings.Contains(err.Error(), "endpoints[0].fixture.dir") {
+		t.Errorf("expected an error at the fixture directory but got %v", err)
+	}
+}
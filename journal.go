@@ -0,0 +1,260 @@
+package fauxmux
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Outcome describes how a Mux answered a request
+type Outcome string
+
+const (
+	// OutcomeResponse is a successful response generated by the endpoint
+	OutcomeResponse Outcome = "response"
+	// OutcomeError is a response chosen from the endpoint's ErrorResponseConfig
+	OutcomeError Outcome = "error"
+	// OutcomeFault is a transport-level fault chosen from the endpoint's FaultConfig
+	OutcomeFault Outcome = "fault"
+	// OutcomeNoRoute is a request no endpoint answers: its path or method is not registered
+	OutcomeNoRoute Outcome = "no_route"
+	// OutcomeRateLimited is a request rejected by the rate limit of the Mux or the endpoint
+	OutcomeRateLimited Outcome = "rate_limited"
+	// OutcomeCancelled is a request cancelled by its client, e.g. on timeout, before it was fully answered
+	OutcomeCancelled Outcome = "cancelled"
+)
+
+// RecordedRequest is a request served by a Mux
+type RecordedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Route is the path pattern of the endpoint that served the request, e.g. "/users/{id}"
+	Route     string      `json:"route"`
+	Query     url.Values  `json:"query"`
+	Header    http.Header `json:"header"`
+	Body      []byte      `json:"body"`
+	Timestamp time.Time   `json:"timestamp"`
+	Outcome   Outcome     `json:"outcome"`
+	// StatusCode is the status code written in response
+	StatusCode int `json:"status_code"`
+	// ErrorResponse is the error response chosen when Outcome is OutcomeError
+	ErrorResponse *ErrorResponse `json:"error_response,omitempty"`
+	// Fault is the fault injected when Outcome is OutcomeFault
+	Fault FaultKind `json:"fault,omitempty"`
+	// Latency is the latency injected before responding; a cancelled request waited only part of it
+	Latency time.Duration `json:"latency"`
+	// BodyLatency is the latency injected between the headers and the body
+	BodyLatency time.Duration `json:"body_latency"`
+	// Variant is the name of the response variant picked when Outcome is OutcomeResponse
+	Variant string `json:"variant,omitempty"`
+}
+
+// Journal records the requests served by a Mux
+type Journal struct {
+	mu       sync.Mutex
+	requests []RecordedRequest
+	limit    int
+}
+
+// Requests returns every recorded request in arrival order
+func (j *Journal) Requests() []RecordedRequest {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]RecordedRequest(nil), j.requests...)
+}
+
+// Find returns the recorded requests with the given method and path, in arrival order, that
+// satisfy every matcher. path matches either the request path or the route pattern that served it.
+// An empty method or path matches any.
+func (j *Journal) Find(method, path string, matchers ...RequestMatcher) []RecordedRequest {
+	found := make([]RecordedRequest, 0)
+	for _, req := range j.Requests() {
+		if method != "" && req.Method != method {
+			continue
+		}
+		if path != "" && req.Path != path && req.Route != path {
+			continue
+		}
+		if matchesAll(req, matchers) {
+			found = append(found, req)
+		}
+	}
+	return found
+}
+
+// Count returns the number of recorded requests Find would return
+func (j *Journal) Count(method, path string, matchers ...RequestMatcher) int {
+	return len(j.Find(method, path, matchers...))
+}
+
+// Reset discards every recorded request
+func (j *Journal) Reset() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.requests = nil
+}
+
+func (j *Journal) record(req RecordedRequest) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.requests = append(j.requests, req)
+	if j.limit > 0 && len(j.requests) > j.limit {
+		j.requests = append([]RecordedRequest(nil), j.requests[len(j.requests)-j.limit:]...)
+	}
+}
+
+// TestingT is the subset of testing.TB used by the journal assertions
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// AssertCalled reports a test error unless exactly times recorded requests match method, path and matchers
+func (j *Journal) AssertCalled(t TestingT, method, path string, times int, matchers ...RequestMatcher) bool {
+	t.Helper()
+	if count := j.Count(method, path, matchers...); count != times {
+		t.Errorf("expected %s %s to be called %d times but it was called %d times", method, path, times, count)
+		return false
+	}
+	return true
+}
+
+// AssertNotCalled reports a test error if any recorded request matches method, path and matchers
+func (j *Journal) AssertNotCalled(t TestingT, method, path string, matchers ...RequestMatcher) bool {
+	t.Helper()
+	return j.AssertCalled(t, method, path, 0, matchers...)
+}
+
+// RequestMatcher reports whether a recorded request satisfies a condition
+type RequestMatcher func(req RecordedRequest) bool
+
+// WithHeader matches requests whose header key has the given value
+func WithHeader(key, value string) RequestMatcher {
+	return func(req RecordedRequest) bool {
+		for _, v := range req.Header.Values(key) {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// WithQuery matches requests whose query parameter key has the given value
+func WithQuery(key, value string) RequestMatcher {
+	return func(req RecordedRequest) bool {
+		for _, v := range req.Query[key] {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// WithBodyContaining matches requests whose body contains s
+func WithBodyContaining(s string) RequestMatcher {
+	return func(req RecordedRequest) bool {
+		return bytes.Contains(req.Body, []byte(s))
+	}
+}
+
+// WithOutcome matches requests answered with the given outcome
+func WithOutcome(outcome Outcome) RequestMatcher {
+	return func(req RecordedRequest) bool {
+		return req.Outcome == outcome
+	}
+}
+
+// WithVariant matches requests answered with the response variant of the given name
+func WithVariant(name string) RequestMatcher {
+	return func(req RecordedRequest) bool {
+		return req.Variant == name
+	}
+}
+
+// WithStatusCode matches requests answered with the given status code
+func WithStatusCode(statusCode int) RequestMatcher {
+	return func(req RecordedRequest) bool {
+		return req.StatusCode == statusCode
+	}
+}
+
+func matchesAll(req RecordedRequest, matchers []RequestMatcher) bool {
+	for _, matcher := range matchers {
+		if !matcher(req) {
+			return false
+		}
+	}
+	return true
+}
+
+type recordKey struct{}
+
+// recordFromContext returns the journal record of the request being served by a Mux
+func recordFromContext(ctx context.Context) *RecordedRequest {
+	req, _ := ctx.Value(recordKey{}).(*RecordedRequest)
+	return req
+}
+
+// recordingWriter captures the status code written in response to a recorded request
+type recordingWriter struct {
+	http.ResponseWriter
+	record *RecordedRequest
+}
+
+func (w *recordingWriter) WriteHeader(statusCode int) {
+	if w.record.StatusCode == 0 {
+		w.record.StatusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.record.StatusCode == 0 {
+		w.record.StatusCode = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// recordRequest serves r with next and records it in the journal of the Mux
+func (fm *Mux) recordRequest(w http.ResponseWriter, r *http.Request, route string, next func(w http.ResponseWriter, r *http.Request)) {
+	record := &RecordedRequest{
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Route:     route,
+		Query:     r.URL.Query(),
+		Header:    r.Header.Clone(),
+		Timestamp: time.Now(),
+	}
+
+	if r.Body != nil {
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Bad Request: failed to read request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		record.Body = body
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	// faults may abort the handler with http.ErrAbortHandler, which must not lose the record
+	defer func() {
+		fm.journal.record(*record)
+		if fm.logger != nil {
+			fm.logger.Debug("fauxmux request", "method", record.Method, "path", record.Path, "route", record.Route,
+				"outcome", record.Outcome, "status", record.StatusCode, "latency", record.Latency)
+		}
+	}()
+	next(&recordingWriter{ResponseWriter: w, record: record}, r.WithContext(context.WithValue(r.Context(), recordKey{}, record)))
+}
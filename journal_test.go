@@ -0,0 +1,137 @@
+package fauxmux
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeT records the errors reported by journal assertions
+type fakeT struct {
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestJournal(t *testing.T) {
+	mux := NewMux()
+
+	err := RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "POST",
+		Path:           "/users/{id}",
+		ResponseFormat: JSON,
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	err = RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/error",
+		ResponseFormat: JSON,
+		ErrorResponseConfig: &ErrorResponseConfig{
+			Frequency: 1,
+			Responses: []ErrorResponse{
+				{StatusCode: 503, Response: Error{Message: "Unavailable"}, ResponseFormat: JSON},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/users/%d?page=%d", i, i), strings.NewReader(`{"name": "Jane"}`))
+		req.Header.Set("Authorization", "Bearer token")
+		mux.Mux().ServeHTTP(httptest.NewRecorder(), req)
+	}
+	mux.Mux().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+	mux.Mux().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/error", nil))
+
+	journal := mux.Journal()
+	if requests := journal.Requests(); len(requests) != 5 {
+		t.Fatalf("expected 5 recorded requests but got %d", len(requests))
+	}
+
+	journal.AssertCalled(t, "POST", "/users/{id}", 3, WithHeader("Authorization", "Bearer token"), WithBodyContaining("Jane"))
+	journal.AssertCalled(t, "POST", "/users/1", 1, WithQuery("page", "1"), WithStatusCode(http.StatusOK))
+	journal.AssertCalled(t, "GET", "/users/1", 1, WithOutcome(OutcomeNoRoute), WithStatusCode(http.StatusMethodNotAllowed))
+	journal.AssertNotCalled(t, "DELETE", "/users/{id}")
+
+	errored := journal.Find("GET", "/error", WithOutcome(OutcomeError))
+	if len(errored) != 1 || errored[0].StatusCode != 503 || errored[0].ErrorResponse == nil || errored[0].ErrorResponse.StatusCode != 503 {
+		t.Fatalf("expected a recorded 503 error response but got %+v", errored)
+	}
+
+	ft := &fakeT{}
+	if journal.AssertCalled(ft, "POST", "/users/{id}", 2) || len(ft.errors) != 1 {
+		t.Fatalf("expected a failed assertion but got %v", ft.errors)
+	}
+
+	journal.Reset()
+	if count := journal.Count("", ""); count != 0 {
+		t.Fatalf("expected an empty journal after reset but got %d requests", count)
+	}
+}
+
+func TestJournal_Limit(t *testing.T) {
+	mux := NewMux(WithJournalLimit(2))
+
+	err := RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/users/{id}",
+		ResponseFormat: JSON,
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		mux.Mux().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", fmt.Sprintf("/users/%d", i), nil))
+	}
+
+	requests := mux.Journal().Requests()
+	if len(requests) != 2 || requests[0].Path != "/users/3" || requests[1].Path != "/users/4" {
+		t.Fatalf("expected the 2 most recent requests but got %+v", requests)
+	}
+}
+
+func TestJournal_NoRoute(t *testing.T) {
+	mux := NewMux()
+
+	err := RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/users/{id}",
+		ResponseFormat: JSON,
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/user/1", nil))
+	if w.Code != http.StatusNotFound || w.Body.String() != "404 page not found\n" {
+		t.Fatalf("expected the 404 of http.ServeMux but got %d %q", w.Code, w.Body.String())
+	}
+	mux.Journal().AssertCalled(t, "GET", "/user/1", 1, WithOutcome(OutcomeNoRoute), WithStatusCode(http.StatusNotFound))
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+	mux.Journal().AssertCalled(t, "GET", "/users/{id}", 1, WithOutcome(OutcomeResponse))
+
+	// the underlying http.ServeMux remains the caller's to extend
+	mux.Mux().HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", "/anything", nil))
+	if w.Code != http.StatusTeapot {
+		t.Errorf("expected the handler registered on / to answer but got status code %d", w.Code)
+	}
+	mux.Journal().AssertNotCalled(t, "GET", "/anything")
+}
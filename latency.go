@@ -0,0 +1,260 @@
+package fauxmux
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// LatencyModel is a distribution of the latency injected before an endpoint responds
+type LatencyModel interface {
+	// Sample draws a latency from the distribution using rng
+	Sample(rng *rand.Rand) time.Duration
+	Validate() error
+}
+
+// FixedLatency always injects the same latency
+type FixedLatency time.Duration
+
+func (l FixedLatency) Sample(*rand.Rand) time.Duration {
+	return time.Duration(l)
+}
+
+func (l FixedLatency) String() string {
+	return fmt.Sprintf("fixed(%v)", time.Duration(l))
+}
+
+func (l FixedLatency) Validate() error {
+	if l < 0 {
+		return fmt.Errorf("latency cannot be negative")
+	}
+	return nil
+}
+
+// UniformLatency injects a latency uniformly distributed between Min and Max
+type UniformLatency struct {
+	Min time.Duration
+	Max time.Duration
+}
+
+func (l UniformLatency) Sample(rng *rand.Rand) time.Duration {
+	if l.Max <= l.Min {
+		return l.Min
+	}
+	return l.Min + time.Duration(rng.Int63n(int64(l.Max-l.Min)))
+}
+
+func (l UniformLatency) String() string {
+	return fmt.Sprintf("uniform(min=%v, max=%v)", l.Min, l.Max)
+}
+
+func (l UniformLatency) Validate() error {
+	if l.Min < 0 {
+		return fieldError("Min", fmt.Errorf("min latency cannot be negative"))
+	}
+
+	if l.Max < l.Min {
+		return fieldError("Max", fmt.Errorf("max latency cannot be less than min latency"))
+	}
+
+	return nil
+}
+
+// NormalLatency injects a normally distributed latency, truncated to [0, Max] (unbounded above if Max is 0)
+type NormalLatency struct {
+	Mean   time.Duration
+	StdDev time.Duration
+	Max    time.Duration
+}
+
+func (l NormalLatency) Sample(rng *rand.Rand) time.Duration {
+	return clampLatency(float64(l.Mean)+rng.NormFloat64()*float64(l.StdDev), 0, l.Max)
+}
+
+func (l NormalLatency) String() string {
+	return fmt.Sprintf("normal(mean=%v, stddev=%v%s)", l.Mean, l.StdDev, maxLatencyString(l.Max))
+}
+
+func (l NormalLatency) Validate() error {
+	if l.Mean < 0 {
+		return fieldError("Mean", fmt.Errorf("mean latency cannot be negative"))
+	}
+
+	if l.StdDev < 0 {
+		return fieldError("StdDev", fmt.Errorf("standard deviation cannot be negative"))
+	}
+
+	return validateMaxLatency(l.Max, l.Mean)
+}
+
+// LogNormalLatency injects a log-normally distributed latency with the given median, a long-tailed
+// distribution typical of real services. Sigma is the standard deviation of the latency's logarithm:
+// the larger it is, the longer the tail. The latency is capped at Max unless it is 0.
+type LogNormalLatency struct {
+	Median time.Duration
+	Sigma  float64
+	Max    time.Duration
+}
+
+func (l LogNormalLatency) Sample(rng *rand.Rand) time.Duration {
+	return clampLatency(float64(l.Median)*math.Exp(rng.NormFloat64()*l.Sigma), 0, l.Max)
+}
+
+func (l LogNormalLatency) String() string {
+	return fmt.Sprintf("lognormal(median=%v, sigma=%v%s)", l.Median, l.Sigma, maxLatencyString(l.Max))
+}
+
+func (l LogNormalLatency) Validate() error {
+	if l.Median <= 0 {
+		return fieldError("Median", fmt.Errorf("median latency must be positive"))
+	}
+
+	if l.Sigma < 0 {
+		return fieldError("Sigma", fmt.Errorf("sigma cannot be negative"))
+	}
+
+	return validateMaxLatency(l.Max, l.Median)
+}
+
+// ExponentialLatency injects Min plus an exponentially distributed latency, so that the mean latency is Mean.
+// The latency is capped at Max unless it is 0.
+type ExponentialLatency struct {
+	Min  time.Duration
+	Mean time.Duration
+	Max  time.Duration
+}
+
+func (l ExponentialLatency) Sample(rng *rand.Rand) time.Duration {
+	return clampLatency(float64(l.Min)+rng.ExpFloat64()*float64(l.Mean-l.Min), l.Min, l.Max)
+}
+
+func (l ExponentialLatency) String() string {
+	return fmt.Sprintf("exponential(min=%v, mean=%v%s)", l.Min, l.Mean, maxLatencyString(l.Max))
+}
+
+func (l ExponentialLatency) Validate() error {
+	if l.Min < 0 {
+		return fieldError("Min", fmt.Errorf("min latency cannot be negative"))
+	}
+
+	if l.Mean < l.Min {
+		return fieldError("Mean", fmt.Errorf("mean latency cannot be less than min latency"))
+	}
+
+	return validateMaxLatency(l.Max, l.Mean)
+}
+
+// PercentileLatency injects latencies matching a table of percentiles, as reported by most monitoring
+// systems. Latencies between percentiles are interpolated linearly. Min is the fastest latency and Max
+// the slowest; Max defaults to P999.
+type PercentileLatency struct {
+	Min  time.Duration
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	P999 time.Duration
+	Max  time.Duration
+}
+
+func (l PercentileLatency) points() ([]float64, []time.Duration) {
+	max := l.Max
+	if max == 0 {
+		max = l.P999
+	}
+	return []float64{0, 0.5, 0.9, 0.99, 0.999, 1},
+		[]time.Duration{l.Min, l.P50, l.P90, l.P99, l.P999, max}
+}
+
+func (l PercentileLatency) Sample(rng *rand.Rand) time.Duration {
+	quantiles, latencies := l.points()
+	u := rng.Float64()
+	for i := 1; i < len(quantiles); i++ {
+		if u <= quantiles[i] {
+			fraction := (u - quantiles[i-1]) / (quantiles[i] - quantiles[i-1])
+			return latencies[i-1] + time.Duration(fraction*float64(latencies[i]-latencies[i-1]))
+		}
+	}
+	return latencies[len(latencies)-1]
+}
+
+func (l PercentileLatency) String() string {
+	return fmt.Sprintf("percentile(min=%v, p50=%v, p90=%v, p99=%v, p999=%v%s)", l.Min, l.P50, l.P90, l.P99, l.P999, maxLatencyString(l.Max))
+}
+
+func (l PercentileLatency) Validate() error {
+	if l.Min < 0 {
+		return fieldError("Min", fmt.Errorf("min latency cannot be negative"))
+	}
+
+	names := []string{"Min", "P50", "P90", "P99", "P999", "Max"}
+	_, latencies := l.points()
+	for i := 1; i < len(latencies); i++ {
+		if latencies[i] < latencies[i-1] {
+			return fieldError(names[i], fmt.Errorf("%s latency cannot be less than %s latency", names[i], names[i-1]))
+		}
+	}
+
+	return nil
+}
+
+// maxLatencyString describes the cap of a distribution, if any
+func maxLatencyString(max time.Duration) string {
+	if max == 0 {
+		return ""
+	}
+	return fmt.Sprintf(", max=%v", max)
+}
+
+func validateMaxLatency(max, typical time.Duration) error {
+	if max < 0 {
+		return fieldError("Max", fmt.Errorf("max latency cannot be negative"))
+	}
+
+	if max > 0 && max < typical {
+		return fieldError("Max", fmt.Errorf("max latency cannot be less than %v", typical))
+	}
+
+	return nil
+}
+
+// clampLatency converts nanoseconds to a Duration within [min, max], max being ignored if 0
+func clampLatency(ns float64, min, max time.Duration) time.Duration {
+	if ns < float64(min) {
+		return min
+	}
+	if max > 0 && ns > float64(max) {
+		return max
+	}
+	if ns > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(ns)
+}
+
+// latencyModel returns the latency distribution of the endpoint: Latency if set,
+// otherwise uniform between MinLatency and MaxLatency
+func (e EndpointConfig) latencyModel() LatencyModel {
+	if e.Latency != nil {
+		return e.Latency
+	}
+	return UniformLatency{Min: e.MinLatency, Max: e.MaxLatency}
+}
+
+// sleepContext waits for d, returning early with the error of ctx if it is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
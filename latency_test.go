@@ -0,0 +1,156 @@
+package fauxmux
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLatencyModel_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		model     LatencyModel
+		wantErr   bool
+		wantField string
+	}{
+		{name: "fixed", model: FixedLatency(time.Millisecond)},
+		{name: "negative fixed", model: FixedLatency(-time.Millisecond), wantErr: true},
+		{name: "uniform", model: UniformLatency{Min: time.Millisecond, Max: 2 * time.Millisecond}},
+		{name: "uniform max less than min", model: UniformLatency{Min: 2 * time.Millisecond, Max: time.Millisecond}, wantErr: true, wantField: "Max"},
+		{name: "normal", model: NormalLatency{Mean: 100 * time.Millisecond, StdDev: 10 * time.Millisecond}},
+		{name: "normal negative stddev", model: NormalLatency{Mean: 100 * time.Millisecond, StdDev: -1}, wantErr: true, wantField: "StdDev"},
+		{name: "normal max less than mean", model: NormalLatency{Mean: 100 * time.Millisecond, Max: time.Millisecond}, wantErr: true, wantField: "Max"},
+		{name: "lognormal", model: LogNormalLatency{Median: 50 * time.Millisecond, Sigma: 0.5, Max: time.Second}},
+		{name: "lognormal zero median", model: LogNormalLatency{Sigma: 0.5}, wantErr: true, wantField: "Median"},
+		{name: "lognormal negative sigma", model: LogNormalLatency{Median: time.Millisecond, Sigma: -1}, wantErr: true, wantField: "Sigma"},
+		{name: "exponential", model: ExponentialLatency{Min: time.Millisecond, Mean: 10 * time.Millisecond}},
+		{name: "exponential mean less than min", model: ExponentialLatency{Min: 10 * time.Millisecond, Mean: time.Millisecond}, wantErr: true, wantField: "Mean"},
+		{name: "percentile", model: PercentileLatency{P50: 10 * time.Millisecond, P90: 50 * time.Millisecond, P99: 200 * time.Millisecond, P999: time.Second}},
+		{name: "percentile decreasing", model: PercentileLatency{P50: 10 * time.Millisecond, P90: 5 * time.Millisecond, P99: 200 * time.Millisecond, P999: time.Second}, wantErr: true, wantField: "P90"},
+		{name: "percentile max less than p999", model: PercentileLatency{P50: 1, P90: 2, P99: 3, P999: 4, Max: 3}, wantErr: true, wantField: "Max"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.model.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantField == "" {
+				return
+			}
+			var fe *FieldError
+			if !errors.As(err, &fe) || fe.Field != tt.wantField {
+				t.Errorf("expected error on field %q but got %v", tt.wantField, err)
+			}
+		})
+	}
+}
+
+func TestLatencyModel_Sample(t *testing.T) {
+	const samples = 10000
+
+	tests := []struct {
+		name       string
+		model      LatencyModel
+		min, max   time.Duration
+		wantMedian time.Duration
+		tolerance  time.Duration
+	}{
+		{name: "fixed", model: FixedLatency(5 * time.Millisecond), min: 5 * time.Millisecond, max: 5 * time.Millisecond, wantMedian: 5 * time.Millisecond},
+		{name: "uniform", model: UniformLatency{Min: 10 * time.Millisecond, Max: 20 * time.Millisecond}, min: 10 * time.Millisecond, max: 20 * time.Millisecond, wantMedian: 15 * time.Millisecond, tolerance: time.Millisecond},
+		{name: "uniform without range", model: UniformLatency{Min: time.Millisecond, Max: time.Millisecond}, min: time.Millisecond, max: time.Millisecond, wantMedian: time.Millisecond},
+		{name: "normal truncated", model: NormalLatency{Mean: 10 * time.Millisecond, StdDev: 20 * time.Millisecond, Max: 30 * time.Millisecond}, min: 0, max: 30 * time.Millisecond, wantMedian: 10 * time.Millisecond, tolerance: 2 * time.Millisecond},
+		{name: "lognormal", model: LogNormalLatency{Median: 50 * time.Millisecond, Sigma: 1, Max: time.Second}, min: 0, max: time.Second, wantMedian: 50 * time.Millisecond, tolerance: 5 * time.Millisecond},
+		{name: "exponential", model: ExponentialLatency{Min: 10 * time.Millisecond, Mean: 20 * time.Millisecond}, min: 10 * time.Millisecond, max: time.Hour, wantMedian: 10*time.Millisecond + 6931*time.Microsecond, tolerance: time.Millisecond},
+		{name: "percentile", model: PercentileLatency{Min: time.Millisecond, P50: 10 * time.Millisecond, P90: 50 * time.Millisecond, P99: 200 * time.Millisecond, P999: time.Second}, min: time.Millisecond, max: time.Second, wantMedian: 10 * time.Millisecond, tolerance: time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := newRand(1)
+			latencies := make([]time.Duration, 0, samples)
+			for i := 0; i < samples; i++ {
+				latency := tt.model.Sample(rng)
+				if latency < tt.min || latency > tt.max {
+					t.Fatalf("expected latency between %v and %v but got %v", tt.min, tt.max, latency)
+				}
+				latencies = append(latencies, latency)
+			}
+
+			slices.Sort(latencies)
+			median := latencies[samples/2]
+			if median < tt.wantMedian-tt.tolerance || median > tt.wantMedian+tt.tolerance {
+				t.Errorf("expected median latency of %v±%v but got %v", tt.wantMedian, tt.tolerance, median)
+			}
+		})
+	}
+}
+
+func TestPercentileLatency_Tail(t *testing.T) {
+	model := PercentileLatency{P50: 10 * time.Millisecond, P90: 50 * time.Millisecond, P99: 200 * time.Millisecond, P999: time.Second}
+	rng := newRand(1)
+
+	const samples = 100000
+	slow := 0
+	for i := 0; i < samples; i++ {
+		if model.Sample(rng) > 200*time.Millisecond {
+			slow++
+		}
+	}
+
+	// 1% of the latencies are above P99
+	if slow < samples/200 || slow > samples*2/100 {
+		t.Errorf("expected about %d latencies above p99 but got %d", samples/100, slow)
+	}
+}
+
+func TestEndpointConfig_ValidateLatency(t *testing.T) {
+	cfg := EndpointConfig{
+		Method:         "GET",
+		Path:           "/users",
+		ResponseFormat: JSON,
+		MaxLatency:     time.Second,
+		Latency:        FixedLatency(time.Millisecond),
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error combining latency with max latency")
+	}
+
+	cfg.MaxLatency = 0
+	cfg.Latency = NormalLatency{Mean: time.Millisecond, StdDev: -1}
+	var fe *FieldError
+	if err := cfg.Validate(); !errors.As(err, &fe) || fe.Field != "Latency.StdDev" {
+		t.Errorf("expected error on field Latency.StdDev but got %v", err)
+	}
+}
+
+func TestLoadConfig_Latency(t *testing.T) {
+	mux := NewMux()
+	yamlConfig := `endpoints:
+  - method: GET
+    path: /status
+    response_format: json
+    response: {status: ok}
+    latency:
+      distribution: fixed
+      value: 20ms
+`
+	if err := LoadConfig(mux, strings.NewReader(yamlConfig)); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("expected latency of at least 20ms but got %v", elapsed)
+	}
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status code %d but got %d", http.StatusOK, w.Code)
+	}
+}
@@ -0,0 +1,714 @@
+package fauxmux
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigError is an error found while loading a declarative endpoint configuration
+type ConfigError struct {
+	File  string
+	Line  int
+	Field string
+	Err   error
+}
+
+func (e *ConfigError) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File)
+		b.WriteString(":")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "%d:", e.Line)
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	if e.Field != "" {
+		b.WriteString(e.Field)
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Err.Error())
+	return b.String()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// fileConfig is the root of a declarative endpoint configuration file
+type fileConfig struct {
+	Endpoints []endpointSpec `yaml:"endpoints"`
+}
+
+// endpointSpec describes an endpoint in a declarative configuration file
+type endpointSpec struct {
+	Method          string              `yaml:"method"`
+	Path            string              `yaml:"path"`
+	MinLatency      time.Duration       `yaml:"min_latency"`
+	MaxLatency      time.Duration       `yaml:"max_latency"`
+	Latency         *latencySpec        `yaml:"latency"`
+	BodyLatency     *latencySpec        `yaml:"body_latency"`
+	ResponseFormat  ResponseFormat      `yaml:"response_format"`
+	ResponseFormats []ResponseFormat    `yaml:"response_formats"`
+	Seed            *int64              `yaml:"seed"`
+	BindPathParams  bool                `yaml:"bind_path_params"`
+	List            *listSpec           `yaml:"list"`
+	Errors          *errorResponsesSpec `yaml:"errors"`
+	Faults          *faultsSpec         `yaml:"faults"`
+	Throttle        *throttleSpec       `yaml:"throttle"`
+	Scenario        *scenarioSpec       `yaml:"scenario"`
+	Script          *scriptSpec         `yaml:"script"`
+	RateLimit       *rateLimitSpec      `yaml:"rate_limit"`
+	Response        any                 `yaml:"response"`
+	Schema          *schemaSpec         `yaml:"schema"`
+	Template        string              `yaml:"template"`
+	Fixture         *fixtureSpec        `yaml:"fixture"`
+	Variants        []variantSpec       `yaml:"variants"`
+}
+
+// variantSpec describes a response variant; one without a response is the endpoint's payload as is
+type variantSpec struct {
+	Name     string  `yaml:"name"`
+	Weight   float64 `yaml:"weight"`
+	Response any     `yaml:"response"`
+}
+
+// fixtureSpec describes a fixture file or directory; relative paths are relative to the configuration file
+type fixtureSpec struct {
+	File        string `yaml:"file"`
+	Dir         string `yaml:"dir"`
+	Param       string `yaml:"param"`
+	Extension   string `yaml:"extension"`
+	ContentType string `yaml:"content_type"`
+}
+
+// latencySpec describes a latency distribution; which fields apply depends on the distribution
+type latencySpec struct {
+	Distribution string        `yaml:"distribution"`
+	Value        time.Duration `yaml:"value"`
+	Min          time.Duration `yaml:"min"`
+	Max          time.Duration `yaml:"max"`
+	Mean         time.Duration `yaml:"mean"`
+	StdDev       time.Duration `yaml:"stddev"`
+	Median       time.Duration `yaml:"median"`
+	Sigma        float64       `yaml:"sigma"`
+	P50          time.Duration `yaml:"p50"`
+	P90          time.Duration `yaml:"p90"`
+	P99          time.Duration `yaml:"p99"`
+	P999         time.Duration `yaml:"p999"`
+}
+
+func (l latencySpec) model() (LatencyModel, error) {
+	switch l.Distribution {
+	case "fixed":
+		return FixedLatency(l.Value), nil
+	case "uniform":
+		return UniformLatency{Min: l.Min, Max: l.Max}, nil
+	case "normal":
+		return NormalLatency{Mean: l.Mean, StdDev: l.StdDev, Max: l.Max}, nil
+	case "lognormal":
+		return LogNormalLatency{Median: l.Median, Sigma: l.Sigma, Max: l.Max}, nil
+	case "exponential":
+		return ExponentialLatency{Min: l.Min, Mean: l.Mean, Max: l.Max}, nil
+	case "percentile":
+		return PercentileLatency{Min: l.Min, P50: l.P50, P90: l.P90, P99: l.P99, P999: l.P999, Max: l.Max}, nil
+	default:
+		return nil, fieldError("Distribution", fmt.Errorf("invalid latency distribution %q", l.Distribution))
+	}
+}
+
+type listSpec struct {
+	MinItems int `yaml:"min_items"`
+	MaxItems int `yaml:"max_items"`
+}
+
+type errorResponsesSpec struct {
+	Frequency float64             `yaml:"frequency"`
+	Responses []errorResponseSpec `yaml:"responses"`
+}
+
+type faultsSpec struct {
+	Frequency float64     `yaml:"frequency"`
+	Kinds     []FaultKind `yaml:"kinds"`
+}
+
+type throttleSpec struct {
+	BytesPerSecond int           `yaml:"bytes_per_second"`
+	ChunkSize      int           `yaml:"chunk_size"`
+	ChunkDelay     time.Duration `yaml:"chunk_delay"`
+}
+
+type scenarioSpec struct {
+	Name          string `yaml:"name"`
+	RequiredState string `yaml:"required_state"`
+	NewState      string `yaml:"new_state"`
+}
+
+type scriptSpec struct {
+	Steps       []scriptStepSpec `yaml:"steps"`
+	OnExhausted ScriptEnd        `yaml:"on_exhausted"`
+}
+
+// scriptStepSpec describes a scripted outcome; an empty step is a successful response
+type scriptStepSpec struct {
+	Latency *latencySpec       `yaml:"latency"`
+	Error   *errorResponseSpec `yaml:"error"`
+	Fault   FaultKind          `yaml:"fault"`
+}
+
+type rateLimitSpec struct {
+	Limit     int                `yaml:"limit"`
+	Window    time.Duration      `yaml:"window"`
+	Algorithm RateLimitAlgorithm `yaml:"algorithm"`
+	Burst     int                `yaml:"burst"`
+	KeyBy     RateLimitKey       `yaml:"key_by"`
+	Header    string             `yaml:"header"`
+	Response  *errorResponseSpec `yaml:"response"`
+}
+
+type errorResponseSpec struct {
+	StatusCode     int               `yaml:"status_code"`
+	Response       any               `yaml:"response"`
+	ResponseFormat ResponseFormat    `yaml:"response_format"`
+	ContentType    string            `yaml:"content_type"`
+	Headers        map[string]string `yaml:"headers"`
+	Weight         float64           `yaml:"weight"`
+}
+
+func (s errorResponseSpec) errorResponse() ErrorResponse {
+	var headers http.Header
+	if len(s.Headers) > 0 {
+		headers = http.Header{}
+		for key, value := range s.Headers {
+			headers.Set(key, value)
+		}
+	}
+	return ErrorResponse{
+		StatusCode:     s.StatusCode,
+		Response:       s.Response,
+		ResponseFormat: s.ResponseFormat,
+		ContentType:    s.ContentType,
+		Headers:        headers,
+		Weight:         s.Weight,
+	}
+}
+
+// specFieldNames maps EndpointConfig field names to their configuration file keys
+var specFieldNames = map[string]string{
+	"Method":              "method",
+	"Path":                "path",
+	"MinLatency":          "min_latency",
+	"MaxLatency":          "max_latency",
+	"Latency":             "latency",
+	"BodyLatency":         "body_latency",
+	"Distribution":        "distribution",
+	"Min":                 "min",
+	"Max":                 "max",
+	"Mean":                "mean",
+	"StdDev":              "stddev",
+	"Median":              "median",
+	"Sigma":               "sigma",
+	"P50":                 "p50",
+	"P90":                 "p90",
+	"P99":                 "p99",
+	"P999":                "p999",
+	"ResponseFormat":      "response_format",
+	"ResponseFormats":     "response_formats",
+	"Seed":                "seed",
+	"BindPathParams":      "bind_path_params",
+	"ListResponseConfig":  "list",
+	"MinItems":            "min_items",
+	"MaxItems":            "max_items",
+	"ErrorResponseConfig": "errors",
+	"Frequency":           "frequency",
+	"Responses":           "responses",
+	"FaultConfig":         "faults",
+	"Kinds":               "kinds",
+	"Throttle":            "throttle",
+	"BytesPerSecond":      "bytes_per_second",
+	"ChunkSize":           "chunk_size",
+	"ChunkDelay":          "chunk_delay",
+	"Scenario":            "scenario",
+	"Name":                "name",
+	"RequiredState":       "required_state",
+	"NewState":            "new_state",
+	"Script":              "script",
+	"Steps":               "steps",
+	"OnExhausted":         "on_exhausted",
+	"Error":               "error",
+	"Fault":               "fault",
+	"RateLimit":           "rate_limit",
+	"Limit":               "limit",
+	"Window":              "window",
+	"Algorithm":           "algorithm",
+	"Burst":               "burst",
+	"KeyBy":               "key_by",
+	"Header":              "header",
+	"StatusCode":          "status_code",
+	"Response":            "response",
+	"ContentType":         "content_type",
+	"Headers":             "headers",
+	"Schema":              "schema",
+	"Template":            "template",
+	"Fixture":             "fixture",
+	"File":                "file",
+	"Dir":                 "dir",
+	"Param":               "param",
+	"Extension":           "extension",
+	"Variants":            "variants",
+	"Weight":              "weight",
+	"ErrorFrequency":      "error_frequency",
+	"FaultFrequency":      "fault_frequency",
+}
+
+// LoadConfigFile registers every endpoint described in the YAML or JSON file at path, or none if
+// any is invalid, defined twice or its path conflicts with another
+func LoadConfigFile(fm *Mux, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+	return loadConfig(fm, path, data)
+}
+
+// LoadConfig registers every endpoint described in the YAML or JSON document read from r, or none
+// if any is invalid, defined twice or its path conflicts with another
+func LoadConfig(fm *Mux, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %v", err)
+	}
+	return loadConfig(fm, "", data)
+}
+
+func loadConfig(fm *Mux, file string, data []byte) error {
+	specs, lines, err := parseConfig(file, data)
+	if err != nil {
+		return err
+	}
+
+	endpointCfgs := make([]EndpointConfig, 0, len(specs))
+	// defined maps the method, path and scenario state of every endpoint to its index, as an endpoint
+	// registered after another with the same ones would silently replace it
+	defined := map[string]int{}
+	for i, spec := range specs {
+		path := fmt.Sprintf("endpoints[%d]", i)
+		endpointCfg, err := spec.endpointConfig(fm)
+		if err == nil && endpointCfg.Fixture != nil && file != "" {
+			fixture := endpointCfg.Fixture.resolve(filepath.Dir(file))
+			endpointCfg.Fixture = &fixture
+		}
+		if err == nil {
+			err = endpointCfg.Validate()
+		}
+		if err != nil {
+			return configErrorAt(file, lines, path, err)
+		}
+
+		key := endpointCfg.Method + " " + endpointCfg.Path + "\x00" + scenarioKey(endpointCfg.Scenario)
+		if j, ok := defined[key]; ok {
+			return configErrorAt(file, lines, path, fieldError("Path", fmt.Errorf("%s %s is already defined by endpoints[%d]", endpointCfg.Method, endpointCfg.Path, j)))
+		}
+		defined[key] = i
+		endpointCfgs = append(endpointCfgs, endpointCfg)
+	}
+
+	// a conflict found while registering would leave the endpoints before it registered
+	paths := make([]string, len(endpointCfgs))
+	for i, endpointCfg := range endpointCfgs {
+		paths[i] = endpointCfg.Path
+	}
+	if i, err := fm.checkPaths(paths); err != nil {
+		return configErrorAt(file, lines, fmt.Sprintf("endpoints[%d]", i), fieldError("Path", err))
+	}
+
+	for i, endpointCfg := range endpointCfgs {
+		if err := RegisterEndpoint[any](fm, endpointCfg); err != nil {
+			return configErrorAt(file, lines, fmt.Sprintf("endpoints[%d]", i), err)
+		}
+	}
+
+	return nil
+}
+
+// parseEndpoint decodes the YAML or JSON description of a single endpoint, as found in the
+// endpoints of a configuration file, into a validated EndpointConfig. It is the input of the admin
+// API, whose callers must not read the files of the server, so fixture files are rejected.
+func parseEndpoint(fm *Mux, data []byte) (EndpointConfig, error) {
+	var spec endpointSpec
+	lines := map[string]int{}
+	if err := decodeDocument(data, &spec, lines); err != nil {
+		return EndpointConfig{}, err
+	}
+
+	endpointCfg, err := spec.endpointConfig(fm)
+	if err == nil && endpointCfg.Fixture != nil {
+		// checked before Validate, which would reveal whether the files exist
+		err = fieldError("Fixture", fmt.Errorf("fixture files cannot be registered through the admin API"))
+	}
+	if err == nil {
+		err = endpointCfg.Validate()
+	}
+	if err != nil {
+		return EndpointConfig{}, configErrorAt("", lines, "", err)
+	}
+	return endpointCfg, nil
+}
+
+// parseConfig decodes data into endpoint specs, recording the line of every field it sees
+func parseConfig(file string, data []byte) ([]endpointSpec, map[string]int, error) {
+	var root yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&root); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil, &ConfigError{File: file, Err: fmt.Errorf("config is empty")}
+		}
+		return nil, nil, &ConfigError{File: file, Err: err}
+	}
+
+	var cfg fileConfig
+	lines := map[string]int{}
+	if err := decodeNode(root.Content[0], "", reflect.ValueOf(&cfg).Elem(), lines); err != nil {
+		var cfgErr *ConfigError
+		if errors.As(err, &cfgErr) {
+			cfgErr.File = file
+		}
+		return nil, nil, err
+	}
+
+	return cfg.Endpoints, lines, nil
+}
+
+// decodeDocument decodes the YAML or JSON document data into v, recording the line of every field it sees
+func decodeDocument(data []byte, v any, lines map[string]int) error {
+	var root yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&root); err != nil {
+		if errors.Is(err, io.EOF) {
+			return &ConfigError{Err: fmt.Errorf("document is empty")}
+		}
+		return &ConfigError{Err: err}
+	}
+	return decodeNode(root.Content[0], "", reflect.ValueOf(v).Elem(), lines)
+}
+
+// decodeNode decodes node into v field by field so that errors can name the offending field
+func decodeNode(node *yaml.Node, path string, v reflect.Value, lines map[string]int) error {
+	lines[path] = node.Line
+
+	switch {
+	case v.Kind() == reflect.Pointer && v.Type().Elem().Kind() == reflect.Struct:
+		if node.Tag == "!!null" {
+			return nil
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		return decodeNode(node, path, v.Elem(), lines)
+
+	case v.Kind() == reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return &ConfigError{Line: node.Line, Field: path, Err: fmt.Errorf("expected a mapping")}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := structFieldByTag(v, key.Value)
+			if !ok {
+				return &ConfigError{Line: key.Line, Field: joinPath(path, key.Value), Err: fmt.Errorf("unknown field")}
+			}
+			if err := decodeNode(value, joinPath(path, key.Value), field, lines); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case v.Kind() == reflect.Slice && isStructLike(v.Type().Elem()):
+		if node.Kind != yaml.SequenceNode {
+			return &ConfigError{Line: node.Line, Field: path, Err: fmt.Errorf("expected a list")}
+		}
+		v.Set(reflect.MakeSlice(v.Type(), len(node.Content), len(node.Content)))
+		for i, item := range node.Content {
+			if err := decodeNode(item, fmt.Sprintf("%s[%d]", path, i), v.Index(i), lines); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case v.Kind() == reflect.Map && isStructLike(v.Type().Elem()):
+		if node.Kind != yaml.MappingNode {
+			return &ConfigError{Line: node.Line, Field: path, Err: fmt.Errorf("expected a mapping")}
+		}
+		v.Set(reflect.MakeMap(v.Type()))
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			item := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeNode(value, joinPath(path, key.Value), item, lines); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(key.Value), item)
+		}
+		return nil
+	}
+
+	if err := node.Decode(v.Addr().Interface()); err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+			msg := typeErr.Errors[0]
+			if _, rest, ok := strings.Cut(msg, ": "); ok && strings.HasPrefix(msg, "line ") {
+				msg = rest
+			}
+			err = errors.New(msg)
+		}
+		return &ConfigError{Line: node.Line, Field: path, Err: err}
+	}
+	return nil
+}
+
+func isStructLike(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+func structFieldByTag(v reflect.Value, name string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		if v.Type().Field(i).Tag.Get("yaml") == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// configErrorAt converts err into a ConfigError located at the most specific known field below path
+func configErrorAt(file string, lines map[string]int, path string, err error) error {
+	field := path
+	var fe *FieldError
+	if errors.As(err, &fe) {
+		field = joinPath(path, specFieldPath(fe.Field))
+		err = fe.Err
+	}
+
+	line := 0
+	for candidate := field; candidate != ""; candidate = parentPath(candidate) {
+		if l, ok := lines[candidate]; ok {
+			line = l
+			break
+		}
+	}
+
+	return &ConfigError{File: file, Line: line, Field: field, Err: err}
+}
+
+// specFieldPath translates a Go field path such as "ErrorResponseConfig.Responses[0].StatusCode"
+// into the matching configuration file path, "errors.responses[0].status_code"
+func specFieldPath(goPath string) string {
+	segments := strings.Split(goPath, ".")
+	for i, segment := range segments {
+		name, index, _ := strings.Cut(segment, "[")
+		if key, ok := specFieldNames[name]; ok {
+			name = key
+		}
+		if index != "" {
+			name += "[" + index
+		}
+		segments[i] = name
+	}
+	return strings.Join(segments, ".")
+}
+
+func parentPath(path string) string {
+	if i := strings.LastIndexAny(path, ".["); i >= 0 {
+		return path[:i]
+	}
+	return ""
+}
+
+// endpointConfig converts the spec into an EndpointConfig that serves its static, schema-described, templated or recorded payload
+func (s endpointSpec) endpointConfig(fm *Mux) (EndpointConfig, error) {
+	endpointCfg := EndpointConfig{
+		Method:          s.Method,
+		Path:            s.Path,
+		MinLatency:      s.MinLatency,
+		MaxLatency:      s.MaxLatency,
+		ResponseFormat:  s.ResponseFormat,
+		ResponseFormats: s.ResponseFormats,
+		Seed:            s.Seed,
+		BindPathParams:  s.BindPathParams,
+	}
+
+	if s.Latency != nil {
+		model, err := s.Latency.model()
+		if err != nil {
+			return EndpointConfig{}, fieldError("Latency", err)
+		}
+		endpointCfg.Latency = model
+	}
+
+	if s.BodyLatency != nil {
+		model, err := s.BodyLatency.model()
+		if err != nil {
+			return EndpointConfig{}, fieldError("BodyLatency", err)
+		}
+		endpointCfg.BodyLatency = model
+	}
+
+	if s.List != nil {
+		endpointCfg.ListResponseConfig = &ListResponseConfig{
+			MinItems: s.List.MinItems,
+			MaxItems: s.List.MaxItems,
+		}
+	}
+
+	if s.Errors != nil {
+		errorCfg := &ErrorResponseConfig{Frequency: s.Errors.Frequency}
+		for _, response := range s.Errors.Responses {
+			errorCfg.Responses = append(errorCfg.Responses, response.errorResponse())
+		}
+		endpointCfg.ErrorResponseConfig = errorCfg
+	}
+
+	for _, variant := range s.Variants {
+		endpointCfg.Variants = append(endpointCfg.Variants, ResponseVariant{
+			Name:     variant.Name,
+			Weight:   variant.Weight,
+			Response: variant.Response,
+		})
+	}
+
+	if s.Faults != nil {
+		endpointCfg.FaultConfig = &FaultConfig{
+			Frequency: s.Faults.Frequency,
+			Kinds:     s.Faults.Kinds,
+		}
+	}
+
+	if s.Throttle != nil {
+		endpointCfg.Throttle = &ThrottleConfig{
+			BytesPerSecond: s.Throttle.BytesPerSecond,
+			ChunkSize:      s.Throttle.ChunkSize,
+			ChunkDelay:     s.Throttle.ChunkDelay,
+		}
+	}
+
+	if s.Scenario != nil {
+		endpointCfg.Scenario = &ScenarioConfig{
+			Name:          s.Scenario.Name,
+			RequiredState: s.Scenario.RequiredState,
+			NewState:      s.Scenario.NewState,
+		}
+	}
+
+	if s.Script != nil {
+		scriptCfg := &ScriptConfig{OnExhausted: s.Script.OnExhausted}
+		for i, step := range s.Script.Steps {
+			scriptStep := ScriptStep{Fault: step.Fault}
+			if step.Latency != nil {
+				model, err := step.Latency.model()
+				if err != nil {
+					return EndpointConfig{}, fieldError(fmt.Sprintf("Script.Steps[%d].Latency", i), err)
+				}
+				scriptStep.Latency = model
+			}
+			if step.Error != nil {
+				response := step.Error.errorResponse()
+				scriptStep.Error = &response
+			}
+			scriptCfg.Steps = append(scriptCfg.Steps, scriptStep)
+		}
+		endpointCfg.Script = scriptCfg
+	}
+
+	if s.RateLimit != nil {
+		endpointCfg.RateLimit = &RateLimitConfig{
+			Limit:     s.RateLimit.Limit,
+			Window:    s.RateLimit.Window,
+			Algorithm: s.RateLimit.Algorithm,
+			Burst:     s.RateLimit.Burst,
+			KeyBy:     s.RateLimit.KeyBy,
+			Header:    s.RateLimit.Header,
+		}
+		if s.RateLimit.Response != nil {
+			response := s.RateLimit.Response.errorResponse()
+			endpointCfg.RateLimit.Response = &response
+		}
+	}
+
+	switch {
+	case s.Fixture != nil && (s.Response != nil || s.Schema != nil || s.Template != ""):
+		return EndpointConfig{}, &FieldError{Field: "Fixture", Err: fmt.Errorf("fixture cannot be combined with response, schema or template")}
+	case s.Fixture != nil:
+		endpointCfg.Fixture = &FixtureConfig{
+			File:        s.Fixture.File,
+			Dir:         s.Fixture.Dir,
+			Param:       s.Fixture.Param,
+			Extension:   s.Fixture.Extension,
+			ContentType: s.Fixture.ContentType,
+		}
+	case s.Template != "" && (s.Response != nil || s.Schema != nil):
+		return EndpointConfig{}, &FieldError{Field: "Template", Err: fmt.Errorf("template cannot be combined with response or schema")}
+	case s.Template != "":
+		endpointCfg.Template = s.Template
+	case s.Response != nil && s.Schema != nil:
+		return EndpointConfig{}, &FieldError{Field: "Schema", Err: fmt.Errorf("response and schema cannot both be set")}
+	case s.Response != nil:
+		response := s.Response
+		endpointCfg.FakeDataFunc = func(v interface{}) error {
+			*v.(*any) = cloneValue(response)
+			return nil
+		}
+	case s.Schema != nil:
+		if err := s.Schema.Validate(); err != nil {
+			return EndpointConfig{}, fieldError("Schema", err)
+		}
+		rng := fm.rand
+		if s.Seed != nil {
+			rng = newRand(*s.Seed)
+		}
+		schema := s.Schema
+		endpointCfg.FakeDataFunc = func(v interface{}) error {
+			*v.(*any) = schema.generate(rng)
+			return nil
+		}
+	default:
+		return EndpointConfig{}, &FieldError{Field: "Response", Err: fmt.Errorf("either response, schema, template or fixture must be set")}
+	}
+
+	return endpointCfg, nil
+}
+
+// cloneValue deep copies the maps and slices of a decoded configuration value
+// so that a response can be modified without affecting later ones
+func cloneValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		clone := make(map[string]any, len(v))
+		for key, value := range v {
+			clone[key] = cloneValue(value)
+		}
+		return clone
+	case []any:
+		clone := make([]any, len(v))
+		for i, value := range v {
+			clone[i] = cloneValue(value)
+		}
+		return clone
+	default:
+		return v
+	}
+}
@@ -0,0 +1,274 @@
+package fauxmux
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testYAMLConfig = `endpoints:
+  - method: GET
+    path: /status
+    response_format: json
+    response:
+      status: ok
+      version: 3
+  - method: GET
+    path: /users
+    max_latency: 1ms
+    response_format: json
+    list:
+      min_items: 3
+      max_items: 3
+    schema:
+      type: object
+      properties:
+        id: {type: integer, min: 1, max: 100}
+        email: {type: string, format: email}
+        role: {enum: [admin, member]}
+`
+
+func TestLoadConfig(t *testing.T) {
+	mux := NewMux(WithSeed(1))
+	if err := LoadConfig(mux, strings.NewReader(testYAMLConfig)); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/status", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code %d but got %d", http.StatusOK, w.Code)
+	}
+	var status map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatalf("failed to unmarshal response body: %v", err)
+	}
+	if status["status"] != "ok" || status["version"] != float64(3) {
+		t.Fatalf("expected static response but got %v", status)
+	}
+
+	w = httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+	var users []struct {
+		ID    int    `json:"id"`
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &users); err != nil {
+		t.Fatalf("failed to unmarshal response body: %v", err)
+	}
+	if len(users) != 3 {
+		t.Fatalf("expected 3 users but got %d", len(users))
+	}
+	for _, user := range users {
+		if user.ID < 1 || user.ID > 100 || !strings.Contains(user.Email, "@") || (user.Role != "admin" && user.Role != "member") {
+			t.Fatalf("expected user matching schema but got %+v", user)
+		}
+	}
+}
+
+func TestLoadConfigFile_JSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fake.json")
+	config := "{\n\t\"endpoints\": [\n\t\t{\n\t\t\t\"method\": \"POST\",\n\t\t\t\"path\": \"/orders\",\n\t\t\t\"response_format\": \"json\",\n\t\t\t\"response\": {\"id\": \"abc\"}\n\t\t}\n\t]\n}\n"
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	mux := NewMux()
+	if err := LoadConfigFile(mux, path); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("POST", "/orders", nil))
+	if strings.TrimSpace(w.Body.String()) != `{"id":"abc"}` {
+		t.Fatalf("expected static response but got %s", w.Body.String())
+	}
+}
+
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    string
+		wantLine  int
+		wantField string
+	}{
+		{
+			name: "validation error",
+			config: `endpoints:
+  - method: GET
+    path: /users
+    response_format: json
+    response: {}
+    errors:
+      frequency: 0.5
+      responses:
+        - status_code: 700
+          response_format: json
+          response: {}
+`,
+			wantLine:  9,
+			wantField: "endpoints[0].errors.responses[0].status_code",
+		},
+		{
+			name: "missing field",
+			config: `endpoints:
+  - path: /users
+    response_format: json
+    response: {}
+`,
+			wantLine:  2,
+			wantField: "endpoints[0].method",
+		},
+		{
+			name: "unknown field",
+			config: `endpoints:
+  - method: GET
+    path: /users
+    delay: 1s
+`,
+			wantLine:  4,
+			wantField: "endpoints[0].delay",
+		},
+		{
+			name: "invalid latency",
+			config: `endpoints:
+  - method: GET
+    path: /users
+    response_format: json
+    response: {}
+    latency:
+      distribution: normal
+      mean: 100ms
+      stddev: -1ms
+`,
+			wantLine:  9,
+			wantField: "endpoints[0].latency.stddev",
+		},
+		{
+			name: "invalid body latency",
+			config: `endpoints:
+  - method: GET
+    path: /users
+    response_format: json
+    response: {}
+    body_latency:
+      distribution: uniform
+      min: 2s
+      max: 1s
+`,
+			wantLine:  9,
+			wantField: "endpoints[0].body_latency.max",
+		},
+		{
+			name: "unknown latency distribution",
+			config: `endpoints:
+  - method: GET
+    path: /users
+    response_format: json
+    response: {}
+    latency:
+      distribution: gamma
+`,
+			wantLine:  7,
+			wantField: "endpoints[0].latency.distribution",
+		},
+		{
+			name: "invalid type",
+			config: `endpoints:
+  - method: GET
+    path: /users
+    min_latency: soon
+`,
+			wantLine:  4,
+			wantField: "endpoints[0].min_latency",
+		},
+		{
+			name: "invalid schema",
+			config: `endpoints:
+  - method: GET
+    path: /users
+    response_format: json
+    schema:
+      type: object
+      properties:
+        id: {type: int}
+`,
+			wantLine:  8,
+			wantField: "endpoints[0].schema.properties.id.type",
+		},
+		{
+			name: "no payload",
+			config: `endpoints:
+  - method: GET
+    path: /users
+    response_format: json
+`,
+			wantLine:  2,
+			wantField: "endpoints[0].response",
+		},
+		{
+			name: "invalid template",
+			config: `endpoints:
+  - method: GET
+    path: /users
+    response_format: json
+    template: '{"id": {{.PathParams.id}'
+`,
+			wantLine:  5,
+			wantField: "endpoints[0].template",
+		},
+		{
+			name: "duplicate endpoints",
+			config: `endpoints:
+  - method: GET
+    path: /a
+    response_format: json
+    response: {}
+  - method: GET
+    path: /a
+    response_format: json
+    response: {}
+`,
+			wantLine:  7,
+			wantField: "endpoints[1].path",
+		},
+		{
+			name: "conflicting paths",
+			config: `endpoints:
+  - method: GET
+    path: /a/{id}
+    response_format: json
+    response: {}
+  - method: GET
+    path: /a/{name}
+    response_format: json
+    response: {}
+`,
+			wantLine:  7,
+			wantField: "endpoints[1].path",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := NewMux()
+			err := LoadConfig(mux, strings.NewReader(tt.config))
+			var cfgErr *ConfigError
+			if !errors.As(err, &cfgErr) {
+				t.Fatalf("LoadConfig() error = %v, want ConfigError", err)
+			}
+			if cfgErr.Line != tt.wantLine || cfgErr.Field != tt.wantField {
+				t.Errorf("LoadConfig() error at line %d field %q, want line %d field %q", cfgErr.Line, cfgErr.Field, tt.wantLine, tt.wantField)
+			}
+			if routes := mux.Routes(); len(routes) != 0 {
+				t.Errorf("expected a failed load to register no endpoint but got %+v", routes)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package fauxmux
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// mediaTypeAliases lists the media types, besides their encoder's content type, accepted for the built-in formats
+var mediaTypeAliases = map[ResponseFormat][]string{
+	XML:         {"text/xml"},
+	YAML:        {"application/x-yaml", "text/yaml"},
+	MessagePack: {"application/x-msgpack"},
+}
+
+// mediaRange is a media range of an Accept header, e.g. "text/*;q=0.5"
+type mediaRange struct {
+	mediaType string
+	subtype   string
+	q         float64
+}
+
+// parseAccept returns the media ranges of an Accept header, ignoring malformed ones
+func parseAccept(accept string) []mediaRange {
+	ranges := make([]mediaRange, 0)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		typ, subtype, ok := strings.Cut(mediaType, "/")
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(value, 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+
+		ranges = append(ranges, mediaRange{mediaType: typ, subtype: subtype, q: q})
+	}
+	return ranges
+}
+
+// quality returns the quality the media ranges give to mediaType: that of the most specific matching range
+func quality(ranges []mediaRange, mediaType string) float64 {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		var s int
+		switch {
+		case r.mediaType == typ && r.subtype == subtype:
+			s = 2
+		case r.mediaType == typ && r.subtype == "*":
+			s = 1
+		case r.mediaType == "*" && r.subtype == "*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+// formatMediaTypes returns the media types served by format
+func formatMediaTypes(format ResponseFormat) []string {
+	enc, ok := lookupEncoder(format)
+	if !ok {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(enc.ContentType())
+	if err != nil {
+		mediaType = enc.ContentType()
+	}
+	return append([]string{mediaType}, mediaTypeAliases[format]...)
+}
+
+// negotiateFormat picks the format of formats preferred by the Accept header, favoring earlier formats on ties.
+// It returns false if the header accepts none of them. An empty header accepts the first format.
+func negotiateFormat(accept string, formats []ResponseFormat) (ResponseFormat, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return formats[0], true
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQ := ResponseFormat(""), 0.0
+	for _, format := range formats {
+		for _, mediaType := range formatMediaTypes(format) {
+			if q := quality(ranges, mediaType); q > bestQ {
+				best, bestQ = format, q
+			}
+		}
+	}
+	return best, bestQ > 0
+}
+
+// responseFormats returns the formats an endpoint can respond with, its default format first
+func (e EndpointConfig) responseFormats() []ResponseFormat {
+	if len(e.ResponseFormats) == 0 {
+		return []ResponseFormat{e.ResponseFormat}
+	}
+	if e.ResponseFormat == "" {
+		return e.ResponseFormats
+	}
+
+	formats := []ResponseFormat{e.ResponseFormat}
+	for _, format := range e.ResponseFormats {
+		if format != e.ResponseFormat {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
@@ -0,0 +1,130 @@
+package fauxmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	formats := []ResponseFormat{JSON, XML, Text}
+
+	tests := []struct {
+		name   string
+		accept string
+		want   ResponseFormat
+		wantOK bool
+	}{
+		{name: "no accept header", accept: "", want: JSON, wantOK: true},
+		{name: "exact match", accept: "application/xml", want: XML, wantOK: true},
+		{name: "alias", accept: "text/xml", want: XML, wantOK: true},
+		{name: "wildcard", accept: "*/*", want: JSON, wantOK: true},
+		{name: "subtype wildcard", accept: "application/*", want: JSON, wantOK: true},
+		{name: "subtype wildcard matches aliases", accept: "text/*", want: XML, wantOK: true},
+		{name: "q values", accept: "application/json;q=0.5, application/xml;q=0.9", want: XML, wantOK: true},
+		{name: "specific range overrides wildcard", accept: "*/*;q=0.1, application/json;q=0", want: XML, wantOK: true},
+		{name: "ties favor earlier formats", accept: "text/plain, application/xml", want: XML, wantOK: true},
+		{name: "parameters ignored", accept: "text/plain; charset=utf-8", want: Text, wantOK: true},
+		{name: "nothing acceptable", accept: "image/png", wantOK: false},
+		{name: "explicitly refused", accept: "application/json;q=0, application/xml;q=0, text/plain;q=0", wantOK: false},
+		{name: "malformed", accept: "json", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := negotiateFormat(tt.accept, formats)
+			if ok != tt.wantOK {
+				t.Fatalf("negotiateFormat() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("negotiateFormat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFauxMuxContentNegotiation(t *testing.T) {
+	mux := NewMux()
+
+	err := RegisterEndpoint[User](mux, EndpointConfig{
+		Method:          "GET",
+		Path:            "/users",
+		ResponseFormat:  JSON,
+		ResponseFormats: []ResponseFormat{XML, JSON},
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	tests := []struct {
+		accept          string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{accept: "", wantStatus: http.StatusOK, wantContentType: "application/json", wantBody: `"name":"Doe"`},
+		{accept: "*/*", wantStatus: http.StatusOK, wantContentType: "application/json", wantBody: `"name":"Doe"`},
+		{accept: "text/xml, application/json;q=0.8", wantStatus: http.StatusOK, wantContentType: "application/xml", wantBody: "<Name>Doe</Name>"},
+		{accept: "text/csv", wantStatus: http.StatusNotAcceptable, wantContentType: "text/plain; charset=utf-8", wantBody: "Not Acceptable"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/users", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		mux.Mux().ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("Accept %q: expected status code %d but got %d", tt.accept, tt.wantStatus, w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); contentType != tt.wantContentType {
+			t.Errorf("Accept %q: expected content type %s but got %s", tt.accept, tt.wantContentType, contentType)
+		}
+		if !strings.Contains(w.Body.String(), tt.wantBody) {
+			t.Errorf("Accept %q: expected body containing %s but got %s", tt.accept, tt.wantBody, w.Body.String())
+		}
+		if w.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q: expected Vary: Accept", tt.accept)
+		}
+	}
+}
+
+func TestEndpointConfig_ValidateResponseFormats(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  EndpointConfig
+		wantErr bool
+	}{
+		{
+			name:    "formats without default",
+			config:  EndpointConfig{Method: "GET", Path: "/test", ResponseFormats: []ResponseFormat{XML, JSON}},
+			wantErr: false,
+		},
+		{
+			name:    "default among formats",
+			config:  EndpointConfig{Method: "GET", Path: "/test", ResponseFormat: JSON, ResponseFormats: []ResponseFormat{XML, JSON}},
+			wantErr: false,
+		},
+		{
+			name:    "default not among formats",
+			config:  EndpointConfig{Method: "GET", Path: "/test", ResponseFormat: CSV, ResponseFormats: []ResponseFormat{XML, JSON}},
+			wantErr: true,
+		},
+		{
+			name:    "unregistered format",
+			config:  EndpointConfig{Method: "GET", Path: "/test", ResponseFormats: []ResponseFormat{XML, "toml"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("EndpointConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package fauxmux
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Option configures a Mux created by NewMux
+type Option func(*Mux)
+
+// WithSeed sets the seed of the random source driving every random decision of the Mux
+// (latency, error triggering and selection, list lengths). Muxes created with the same
+// seed and receiving the same sequence of requests produce the same responses.
+func WithSeed(seed int64) Option {
+	return func(fm *Mux) {
+		fm.seed = seed
+	}
+}
+
+// WithFakeDataFunc sets the FakeDataFunc of the endpoints of the Mux that don't set their own,
+// instead of the one set up with Setup
+func WithFakeDataFunc(fakeDataFunc FakeDataFunc) Option {
+	return func(fm *Mux) {
+		fm.fakeData = fakeDataFunc
+	}
+}
+
+// WithDefaultLatency sets the latency distribution of the endpoints of the Mux that set neither
+// Latency nor MinLatency and MaxLatency. It panics if latency is invalid.
+func WithDefaultLatency(latency LatencyModel) Option {
+	if err := latency.Validate(); err != nil {
+		panic(fmt.Sprintf("fauxmux: invalid default latency: %v", err))
+	}
+	return func(fm *Mux) {
+		fm.defaultLatency = latency
+	}
+}
+
+// WithDefaultErrors sets the error responses of the endpoints of the Mux that don't set an
+// ErrorResponseConfig. It panics if cfg is invalid.
+func WithDefaultErrors(cfg ErrorResponseConfig) Option {
+	if err := cfg.Validate(); err != nil {
+		panic(fmt.Sprintf("fauxmux: invalid default errors: %v", err))
+	}
+	return func(fm *Mux) {
+		fm.defaultErrors = &cfg
+	}
+}
+
+// WithLogger logs every request recorded in the journal of the Mux at debug level, with
+// the way it was answered
+func WithLogger(logger *slog.Logger) Option {
+	return func(fm *Mux) {
+		fm.logger = logger
+	}
+}
+
+// WithLatencyMultiplier scales every latency injected by the Mux by multiplier,
+// e.g. 0 disables latency and 2 doubles it
+func WithLatencyMultiplier(multiplier float64) Option {
+	return func(fm *Mux) {
+		fm.latencyMultiplier = multiplier
+	}
+}
+
+// WithRateLimit rate limits the requests to every endpoint of the Mux, counted across endpoints.
+// It panics if cfg is invalid.
+func WithRateLimit(cfg RateLimitConfig) Option {
+	if err := cfg.Validate(); err != nil {
+		panic(fmt.Sprintf("fauxmux: invalid rate limit: %v", err))
+	}
+	return func(fm *Mux) {
+		fm.rateLimiter = newRateLimiter(cfg)
+	}
+}
+
+// WithAdmin mounts the admin API of the Mux, see AdminHandler, under prefix, e.g. "/__admin"
+func WithAdmin(prefix string) Option {
+	return func(fm *Mux) {
+		fm.adminPrefix = strings.TrimSuffix(prefix, "/")
+	}
+}
+
+// WithJournalLimit bounds the journal of the Mux to the most recent limit requests
+func WithJournalLimit(limit int) Option {
+	return func(fm *Mux) {
+		fm.journal.limit = limit
+	}
+}
@@ -0,0 +1,169 @@
+package fauxmux
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// pathParamNames returns the names of the wildcards of an http.ServeMux pattern,
+// e.g. ["id", "path"] for "/users/{id}/files/{path...}"
+func pathParamNames(pattern string) ([]string, error) {
+	names := make([]string, 0)
+	for _, segment := range strings.Split(pattern, "/") {
+		open, close := strings.Index(segment, "{"), strings.LastIndex(segment, "}")
+		if open < 0 && close < 0 {
+			continue
+		}
+		if open != 0 || close != len(segment)-1 {
+			return nil, fmt.Errorf("wildcard %q must be a full path segment", segment)
+		}
+
+		name := segment[1 : len(segment)-1]
+		if name == "$" {
+			continue
+		}
+		name = strings.TrimSuffix(name, "...")
+		if name == "" || strings.ContainsAny(name, "{}.") {
+			return nil, fmt.Errorf("invalid wildcard %q", segment)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// pathParams returns the values captured by the named wildcards for r
+func pathParams(r *http.Request, names []string) map[string]string {
+	params := make(map[string]string, len(names))
+	for _, name := range names {
+		params[name] = r.PathValue(name)
+	}
+	return params
+}
+
+// bindPathParams sets the fields (or map keys) of response named like a path parameter to the parameter's
+// value. response may be a pointer to a struct or map, or a slice of them, in which case every item is bound.
+func bindPathParams(response any, params map[string]string) error {
+	if len(params) == 0 {
+		return nil
+	}
+	return bindValue(reflect.ValueOf(response), params)
+}
+
+func bindValue(v reflect.Value, params map[string]string) error {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return bindValue(v.Elem(), params)
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := bindValue(v.Index(i), params); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		for name, value := range params {
+			key := reflect.ValueOf(name).Convert(v.Type().Key())
+			current := v.MapIndex(key)
+			if !current.IsValid() {
+				continue
+			}
+			item := reflect.New(v.Type().Elem()).Elem()
+			if current.Kind() == reflect.Interface && !current.IsNil() {
+				// keep the type of dynamically typed values, e.g. numbers stay numbers
+				item = reflect.New(current.Elem().Type()).Elem()
+			}
+			if err := setFromString(item, value); err != nil {
+				return fmt.Errorf("path parameter %s: %v", name, err)
+			}
+			v.SetMapIndex(key, item)
+		}
+
+	case reflect.Struct:
+		if !v.CanSet() {
+			return nil
+		}
+		for _, field := range reflect.VisibleFields(v.Type()) {
+			if !field.IsExported() || field.Anonymous {
+				continue
+			}
+			name, ok := fieldParamName(field, params)
+			if !ok {
+				continue
+			}
+			if err := setFromString(v.FieldByIndex(field.Index), params[name]); err != nil {
+				return fmt.Errorf("path parameter %s: %v", field.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// fieldParamName returns the path parameter bound to field: the one named like its JSON name or,
+// failing that, like its Go name ignoring case
+func fieldParamName(field reflect.StructField, params map[string]string) (string, bool) {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" {
+		if _, ok := params[name]; ok {
+			return name, true
+		}
+	}
+	for name := range params {
+		if strings.EqualFold(name, field.Name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// setFromString parses s into v according to v's kind
+func setFromString(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := setFromString(elem.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(s))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("cannot bind to %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package fauxmux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPathParamNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+		wantErr bool
+	}{
+		{name: "literal", pattern: "/users", want: []string{}},
+		{name: "single wildcard", pattern: "/users/{id}", want: []string{"id"}},
+		{name: "remainder wildcard", pattern: "/users/{id}/files/{path...}", want: []string{"id", "path"}},
+		{name: "end anchor", pattern: "/users/{$}", want: []string{}},
+		{name: "partial segment", pattern: "/users/id-{id}", wantErr: true},
+		{name: "empty wildcard", pattern: "/users/{}", wantErr: true},
+		{name: "unclosed wildcard", pattern: "/users/{id", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pathParamNames(tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("pathParamNames() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pathParamNames() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindPathParams(t *testing.T) {
+	type file struct {
+		Owner uint   `json:"owner_id"`
+		Path  string `json:"path"`
+		Size  *int
+	}
+
+	tests := []struct {
+		name     string
+		response any
+		params   map[string]string
+		want     any
+		wantErr  bool
+	}{
+		{
+			name:     "struct by json name",
+			response: &User{ID: 1, Name: "Doe"},
+			params:   map[string]string{"id": "42"},
+			want:     &User{ID: 42, Name: "Doe"},
+		},
+		{
+			name:     "struct by field name",
+			response: &file{},
+			params:   map[string]string{"owner_id": "7", "path": "a/b.txt", "size": "10"},
+			want:     &file{Owner: 7, Path: "a/b.txt", Size: func() *int { n := 10; return &n }()},
+		},
+		{
+			name:     "list items",
+			response: []User{{ID: 1}, {ID: 2}},
+			params:   map[string]string{"name": "Doe"},
+			want:     []User{{ID: 1, Name: "Doe"}, {ID: 2, Name: "Doe"}},
+		},
+		{
+			name:     "map keeps value types",
+			response: func() *any { var v any = map[string]any{"id": 1, "name": "x"}; return &v }(),
+			params:   map[string]string{"id": "42", "other": "y"},
+			want:     func() *any { var v any = map[string]any{"id": 42, "name": "x"}; return &v }(),
+		},
+		{
+			name:     "invalid value",
+			response: &User{},
+			params:   map[string]string{"id": "abc"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := bindPathParams(tt.response, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("bindPathParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(tt.response, tt.want) {
+				t.Errorf("bindPathParams() = %+v, want %+v", tt.response, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package fauxmux
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitAlgorithm is the algorithm counting the requests of a rate limited client
+type RateLimitAlgorithm string
+
+const (
+	// FixedWindow allows Limit requests per Window, the window starting with the first request of a client
+	FixedWindow RateLimitAlgorithm = "fixed_window"
+	// TokenBucket allows bursts of up to Burst requests, refilled at Limit requests per Window
+	TokenBucket RateLimitAlgorithm = "token_bucket"
+)
+
+// RateLimitKey identifies the client a request is counted against
+type RateLimitKey string
+
+const (
+	// RateLimitByIP counts requests per client IP address
+	RateLimitByIP RateLimitKey = "ip"
+	// RateLimitByHeader counts requests per value of the Header request header
+	RateLimitByHeader RateLimitKey = "header"
+	// RateLimitByAPIKey counts requests per API key, read from the Header request header,
+	// X-API-Key by default, or else from the api_key query parameter
+	RateLimitByAPIKey RateLimitKey = "api_key"
+)
+
+// defaultAPIKeyHeader is the header holding the API key of requests rate limited by API key
+const defaultAPIKeyHeader = "X-API-Key"
+
+// RateLimitConfig rejects the requests of clients exceeding a rate with 429 Too Many Requests.
+// Every response carries X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers,
+// the latter in seconds, and rejected ones a Retry-After header.
+type RateLimitConfig struct {
+	// Limit is the number of requests allowed per Window
+	Limit  int
+	Window time.Duration
+	// Algorithm defaults to FixedWindow
+	Algorithm RateLimitAlgorithm
+	// Burst is the capacity of a TokenBucket, defaulting to Limit
+	Burst int
+	// KeyBy defaults to RateLimitByIP; requests without a key share a single limit
+	KeyBy RateLimitKey
+	// Header is the request header holding the key of RateLimitByHeader and RateLimitByAPIKey
+	Header string
+	// Response, when set, replaces the default 429 Too Many Requests response
+	Response *ErrorResponse
+}
+
+func (c RateLimitConfig) Validate() error {
+	if c.Limit <= 0 {
+		return fieldError("Limit", fmt.Errorf("limit must be positive"))
+	}
+
+	if c.Window <= 0 {
+		return fieldError("Window", fmt.Errorf("window must be positive"))
+	}
+
+	switch c.Algorithm {
+	case "", FixedWindow, TokenBucket:
+	default:
+		return fieldError("Algorithm", fmt.Errorf("invalid rate limit algorithm %q", c.Algorithm))
+	}
+
+	if c.Burst < 0 {
+		return fieldError("Burst", fmt.Errorf("burst cannot be negative"))
+	}
+
+	switch c.KeyBy {
+	case "", RateLimitByIP, RateLimitByAPIKey:
+	case RateLimitByHeader:
+		if c.Header == "" {
+			return fieldError("Header", fmt.Errorf("header cannot be empty when keying by header"))
+		}
+	default:
+		return fieldError("KeyBy", fmt.Errorf("invalid rate limit key %q", c.KeyBy))
+	}
+
+	if c.Response != nil {
+		if err := c.Response.Validate(); err != nil {
+			return fieldError("Response", err)
+		}
+	}
+
+	return nil
+}
+
+// key returns the key r is counted against
+func (c RateLimitConfig) key(r *http.Request) string {
+	switch c.KeyBy {
+	case RateLimitByHeader:
+		return r.Header.Get(c.Header)
+	case RateLimitByAPIKey:
+		header := c.Header
+		if header == "" {
+			header = defaultAPIKeyHeader
+		}
+		if key := r.Header.Get(header); key != "" {
+			return key
+		}
+		return r.URL.Query().Get("api_key")
+	default:
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return r.RemoteAddr
+		}
+		return host
+	}
+}
+
+// rateLimiter enforces a RateLimitConfig, keeping the count of every client seen within the last window
+type rateLimiter struct {
+	cfg     RateLimitConfig
+	mu      sync.Mutex
+	clients map[string]*rateLimitState
+	pruned  time.Time
+	now     func() time.Time
+}
+
+// rateLimitState is the count of a client: the requests of its window, or the tokens left in its bucket
+type rateLimitState struct {
+	start  time.Time
+	count  int
+	tokens float64
+}
+
+func newRateLimiter(cfg RateLimitConfig) *rateLimiter {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = FixedWindow
+	}
+	if cfg.Burst == 0 {
+		cfg.Burst = cfg.Limit
+	}
+	return &rateLimiter{
+		cfg:     cfg,
+		clients: map[string]*rateLimitState{},
+		now:     time.Now,
+	}
+}
+
+// take counts a request of the client identified by key and reports whether it is allowed, the
+// requests it has left, when its limit resets and, if not allowed, when it may retry
+func (l *rateLimiter) take(key string) (allowed bool, remaining int, reset, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+	state, ok := l.clients[key]
+	if !ok {
+		state = &rateLimitState{start: now, tokens: float64(l.cfg.Burst)}
+		l.clients[key] = state
+	}
+
+	if l.cfg.Algorithm == TokenBucket {
+		// tokens per nanosecond
+		rate := float64(l.cfg.Limit) / float64(l.cfg.Window)
+		state.tokens = math.Min(float64(l.cfg.Burst), state.tokens+float64(now.Sub(state.start))*rate)
+		state.start = now
+
+		if state.tokens >= 1 {
+			state.tokens--
+			allowed = true
+		} else {
+			retryAfter = time.Duration((1 - state.tokens) / rate)
+		}
+		reset = time.Duration((float64(l.cfg.Burst) - state.tokens) / rate)
+		return allowed, int(state.tokens), reset, retryAfter
+	}
+
+	if now.Sub(state.start) >= l.cfg.Window {
+		state.start = now
+		state.count = 0
+	}
+	reset = state.start.Add(l.cfg.Window).Sub(now)
+
+	if state.count < l.cfg.Limit {
+		state.count++
+		return true, l.cfg.Limit - state.count, reset, 0
+	}
+	return false, 0, reset, reset
+}
+
+// prune drops, at most once per window, the clients counted as if they had never been seen: those
+// whose window has passed or whose bucket is full again
+func (l *rateLimiter) prune(now time.Time) {
+	if now.Sub(l.pruned) < l.cfg.Window {
+		return
+	}
+	l.pruned = now
+
+	// tokens per nanosecond
+	rate := float64(l.cfg.Limit) / float64(l.cfg.Window)
+	for key, state := range l.clients {
+		elapsed := now.Sub(state.start)
+		if l.cfg.Algorithm == TokenBucket && state.tokens+float64(elapsed)*rate < float64(l.cfg.Burst) {
+			continue
+		}
+		if l.cfg.Algorithm != TokenBucket && elapsed < l.cfg.Window {
+			continue
+		}
+		delete(l.clients, key)
+	}
+}
+
+// reset forgets the requests counted for every client
+func (l *rateLimiter) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clients = map[string]*rateLimitState{}
+}
+
+// limit counts r and sets the rate limit headers of its response. A request exceeding the limit is
+// answered with the configured response and limit returns false.
+func (l *rateLimiter) limit(w http.ResponseWriter, r *http.Request) bool {
+	allowed, remaining, reset, retryAfter := l.take(l.cfg.key(r))
+
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(l.cfg.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(reset)))
+	if allowed {
+		return true
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(retryAfter))))
+	response := ErrorResponse{
+		StatusCode:     http.StatusTooManyRequests,
+		Response:       "Too Many Requests",
+		ResponseFormat: Text,
+	}
+	if l.cfg.Response != nil {
+		response = *l.cfg.Response
+	}
+	writeErrorResponse(w, response)
+	return false
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,219 @@
+package fauxmux
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  RateLimitConfig
+		wantErr bool
+	}{
+		{name: "fixed window", config: RateLimitConfig{Limit: 10, Window: time.Second}},
+		{name: "token bucket by api key", config: RateLimitConfig{Limit: 10, Window: time.Second, Algorithm: TokenBucket, Burst: 20, KeyBy: RateLimitByAPIKey}},
+		{name: "no limit", config: RateLimitConfig{Window: time.Second}, wantErr: true},
+		{name: "no window", config: RateLimitConfig{Limit: 10}, wantErr: true},
+		{name: "negative burst", config: RateLimitConfig{Limit: 10, Window: time.Second, Burst: -1}, wantErr: true},
+		{name: "unknown algorithm", config: RateLimitConfig{Limit: 10, Window: time.Second, Algorithm: "leaky_bucket"}, wantErr: true},
+		{name: "unknown key", config: RateLimitConfig{Limit: 10, Window: time.Second, KeyBy: "cookie"}, wantErr: true},
+		{name: "header key without header", config: RateLimitConfig{Limit: 10, Window: time.Second, KeyBy: RateLimitByHeader}, wantErr: true},
+		{name: "invalid response", config: RateLimitConfig{Limit: 10, Window: time.Second, Response: &ErrorResponse{StatusCode: 429}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeClock is a settable time source for rate limiters
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestRateLimiter_Take(t *testing.T) {
+	type take struct {
+		after       time.Duration
+		wantAllowed bool
+		wantLeft    int
+	}
+	tests := []struct {
+		name  string
+		cfg   RateLimitConfig
+		takes []take
+	}{
+		{
+			name: "fixed window",
+			cfg:  RateLimitConfig{Limit: 2, Window: time.Second},
+			takes: []take{
+				{wantAllowed: true, wantLeft: 1},
+				{after: 100 * time.Millisecond, wantAllowed: true, wantLeft: 0},
+				{after: 100 * time.Millisecond, wantAllowed: false},
+				{after: 800 * time.Millisecond, wantAllowed: true, wantLeft: 1},
+			},
+		},
+		{
+			name: "token bucket",
+			cfg:  RateLimitConfig{Limit: 2, Window: time.Second, Algorithm: TokenBucket, Burst: 3},
+			takes: []take{
+				{wantAllowed: true, wantLeft: 2},
+				{wantAllowed: true, wantLeft: 1},
+				{wantAllowed: true, wantLeft: 0},
+				{wantAllowed: false},
+				// a token is refilled every 500ms
+				{after: 500 * time.Millisecond, wantAllowed: true, wantLeft: 0},
+				{after: 1500 * time.Millisecond, wantAllowed: true, wantLeft: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			limiter := newRateLimiter(tt.cfg)
+			limiter.now = clock.Now
+
+			for i, take := range tt.takes {
+				clock.now = clock.now.Add(take.after)
+				allowed, left, _, retryAfter := limiter.take("client")
+				if allowed != take.wantAllowed || left != take.wantLeft {
+					t.Fatalf("take %d: expected allowed %v with %d left but got %v with %d left", i, take.wantAllowed, take.wantLeft, allowed, left)
+				}
+				if !allowed && retryAfter <= 0 {
+					t.Fatalf("take %d: expected a retry delay", i)
+				}
+			}
+
+			if allowed, _, _, _ := limiter.take("other client"); !allowed {
+				t.Error("expected clients to be limited independently")
+			}
+		})
+	}
+}
+
+func TestRateLimiter_Prune(t *testing.T) {
+	for _, algorithm := range []RateLimitAlgorithm{FixedWindow, TokenBucket} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			limiter := newRateLimiter(RateLimitConfig{Limit: 2, Window: time.Second, Algorithm: algorithm})
+			limiter.now = clock.Now
+
+			for i := 0; i < 100; i++ {
+				limiter.take(fmt.Sprintf("client %d", i))
+			}
+			clock.now = clock.now.Add(500 * time.Millisecond)
+			limiter.take("recent client")
+			if len(limiter.clients) != 101 {
+				t.Fatalf("expected 101 clients within the window but got %d", len(limiter.clients))
+			}
+
+			clock.now = clock.now.Add(time.Second)
+			limiter.take("new client")
+			if len(limiter.clients) != 1 {
+				t.Errorf("expected only the new client to be kept but got %d clients", len(limiter.clients))
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	mux := NewMux()
+
+	err := RegisterEndpoint[string](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/limited",
+		ResponseFormat: Text,
+		RateLimit:      &RateLimitConfig{Limit: 2, Window: time.Minute, KeyBy: RateLimitByAPIKey},
+		FakeDataFunc: func(v interface{}) error {
+			*v.(*string) = "ok"
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	get := func(apiKey string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/limited", nil)
+		r.Header.Set("X-API-Key", apiKey)
+		w := httptest.NewRecorder()
+		mux.Mux().ServeHTTP(w, r)
+		return w
+	}
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		w := get("alice")
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected status code %d but got %d", i, http.StatusOK, w.Code)
+		}
+		if w.Header().Get("X-RateLimit-Limit") != "2" || w.Header().Get("X-RateLimit-Remaining") != wantRemaining {
+			t.Fatalf("request %d: expected %s requests remaining out of 2 but got headers %v", i, wantRemaining, w.Header())
+		}
+	}
+
+	w := get("alice")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected status code %d but got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if w.Header().Get("Retry-After") != "60" || w.Header().Get("X-RateLimit-Reset") != "60" {
+		t.Errorf("expected to retry after the 60s window but got headers %v", w.Header())
+	}
+
+	if w := get("bob"); w.Code != http.StatusOK {
+		t.Errorf("expected another API key to be allowed but got status code %d", w.Code)
+	}
+
+	mux.Journal().AssertCalled(t, "GET", "/limited", 1, WithOutcome(OutcomeRateLimited), WithStatusCode(http.StatusTooManyRequests))
+}
+
+func TestWithRateLimit(t *testing.T) {
+	mux := NewMux(WithRateLimit(RateLimitConfig{
+		Limit:  1,
+		Window: time.Minute,
+		Response: &ErrorResponse{
+			StatusCode:     http.StatusServiceUnavailable,
+			Response:       map[string]string{"error": "slow down"},
+			ResponseFormat: JSON,
+		},
+	}))
+
+	for _, path := range []string{"/a", "/b"} {
+		err := RegisterEndpoint[string](mux, EndpointConfig{
+			Method:         "GET",
+			Path:           path,
+			ResponseFormat: Text,
+			FakeDataFunc: func(v interface{}) error {
+				*v.(*string) = "ok"
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to register endpoint: %v", err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/a", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code %d but got %d", http.StatusOK, w.Code)
+	}
+
+	// the limit of the Mux is shared by its endpoints
+	w = httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/b", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Body.String() != `{"error":"slow down"}`+"\n" {
+		t.Errorf("expected the configured rate limit response but got %d %q", w.Code, w.Body.String())
+	}
+}
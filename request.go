@@ -0,0 +1,85 @@
+package fauxmux
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// Request is the view of an incoming request given to response hooks
+type Request struct {
+	Method string
+	Path   string
+	// PathParams holds the values captured by the wildcards of the endpoint's path
+	PathParams map[string]string
+	Query      url.Values
+	Header     http.Header
+	Body       []byte
+	// HTTPRequest is the underlying request; its body can be read again
+	HTTPRequest *http.Request
+}
+
+// newRequest buffers the body of r so that it can be inspected by hooks and read again by the caller
+func newRequest(r *http.Request, paramNames []string) (*Request, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %v", err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	return &Request{
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		PathParams:  pathParams(r, paramNames),
+		Query:       r.URL.Query(),
+		Header:      r.Header,
+		Body:        body,
+		HTTPRequest: r,
+	}, nil
+}
+
+// DecodeJSON decodes the JSON request body into v
+func (r *Request) DecodeJSON(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// JSON returns the request body decoded as JSON, or nil if the body is not valid JSON
+func (r *Request) JSON() any {
+	var v any
+	if err := r.DecodeJSON(&v); err != nil {
+		return nil
+	}
+	return v
+}
+
+// ResponseHook is called with the incoming request and the freshly faked response, a *T or,
+// for list endpoints, a []T. It may mutate the response or replace it; the returned value is written.
+type ResponseHook func(r *Request, response any) (any, error)
+
+// TypedResponseHook adapts fn, which mutates or replaces the faked *T in place, into a ResponseHook.
+// For list endpoints fn is called for every item.
+func TypedResponseHook[T any](fn func(r *Request, response *T) error) ResponseHook {
+	return func(r *Request, response any) (any, error) {
+		switch response := response.(type) {
+		case *T:
+			return response, fn(r, response)
+		case []T:
+			for i := range response {
+				if err := fn(r, &response[i]); err != nil {
+					return nil, err
+				}
+			}
+			return response, nil
+		default:
+			return nil, fmt.Errorf("unexpected response type %T", response)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package fauxmux
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseHook(t *testing.T) {
+	mux := NewMux()
+
+	err := RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "POST",
+		Path:           "/teams/{team}/users",
+		ResponseFormat: JSON,
+		ResponseHook: TypedResponseHook(func(r *Request, user *User) error {
+			var posted User
+			if err := r.DecodeJSON(&posted); err != nil {
+				return err
+			}
+			user.Name = posted.Name
+			user.Email = fmt.Sprintf("%s@%s.example.com", r.Query.Get("alias"), r.PathParams["team"])
+			return nil
+		}),
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/teams/core/users?alias=jd", strings.NewReader(`{"name": "Jane"}`))
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, req)
+
+	var user User
+	if err := json.Unmarshal(w.Body.Bytes(), &user); err != nil {
+		t.Fatalf("failed to unmarshal response body: %v", err)
+	}
+	if user.ID != 1 || user.Name != "Jane" || user.Email != "jd@core.example.com" {
+		t.Fatalf("expected hooked user but got %+v", user)
+	}
+
+	req = httptest.NewRequest("POST", "/teams/core/users", strings.NewReader(`not json`))
+	w = httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, req)
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status code %d but got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestResponseHook_List(t *testing.T) {
+	mux := NewMux()
+
+	err := RegisterEndpoint[User](mux, EndpointConfig{
+		Method:             "GET",
+		Path:               "/users",
+		ResponseFormat:     JSON,
+		ListResponseConfig: &ListResponseConfig{MinItems: 3, MaxItems: 3},
+		ResponseHook: func(r *Request, response any) (any, error) {
+			users := response.([]User)
+			if limit := r.Query.Get("limit"); limit == "1" {
+				return users[:1], nil
+			}
+			return users, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/users?limit=1", nil))
+
+	var users []User
+	if err := json.Unmarshal(w.Body.Bytes(), &users); err != nil {
+		t.Fatalf("failed to unmarshal response body: %v", err)
+	}
+	if len(users) != 1 {
+		t.Fatalf("expected 1 user but got %d", len(users))
+	}
+}
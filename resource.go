@@ -0,0 +1,399 @@
+package fauxmux
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResourceConfig configures a stateful resource registered with RegisterResource
+type ResourceConfig struct {
+	// Path is the collection path, e.g. "/things"; items are served under Path + "/{id}"
+	Path string
+	// IDField is the JSON (or Go) name of the field of T holding the item ID, "id" by default
+	IDField string
+	// SeedItems is the number of faked items the resource starts with
+	SeedItems           int
+	MinLatency          time.Duration
+	MaxLatency          time.Duration
+	FakeDataFunc        FakeDataFunc
+	ErrorResponseConfig *ErrorResponseConfig
+}
+
+func (c ResourceConfig) Validate() error {
+	if c.SeedItems < 0 {
+		return fieldError("SeedItems", fmt.Errorf("seed items cannot be negative"))
+	}
+
+	if strings.HasSuffix(c.Path, "/") {
+		return fieldError("Path", fmt.Errorf("path cannot end with a slash"))
+	}
+
+	return c.endpointConfig(http.MethodGet, c.Path).Validate()
+}
+
+func (c ResourceConfig) endpointConfig(method, path string) EndpointConfig {
+	return EndpointConfig{
+		Method:              method,
+		Path:                path,
+		MinLatency:          c.MinLatency,
+		MaxLatency:          c.MaxLatency,
+		FakeDataFunc:        c.FakeDataFunc,
+		ResponseFormat:      JSON,
+		ErrorResponseConfig: c.ErrorResponseConfig,
+	}
+}
+
+// Resource is an in-memory collection of T served over HTTP by RegisterResource
+type Resource[T any] struct {
+	mu      sync.RWMutex
+	items   map[string]T
+	order   []string
+	nextID  int64
+	initial []T
+
+	idField []int
+	idKind  reflect.Kind
+	fm      *Mux
+}
+
+// RegisterResource registers POST and GET on cfg.Path and GET, PUT, PATCH and DELETE on cfg.Path + "/{id}",
+// backed by an in-memory store of T seeded with cfg.SeedItems faked items. T must be a struct with a string
+// or integer ID field; items created without an ID are given the next free one.
+func RegisterResource[T any](fm *Mux, cfg ResourceConfig) (*Resource[T], error) {
+	if cfg.IDField == "" {
+		cfg.IDField = "id"
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to register resource: %v", err)
+	}
+
+	idField, ok := findIDField(reflect.TypeFor[T](), cfg.IDField)
+	if !ok {
+		return nil, fmt.Errorf("failed to register resource: %T has no ID field %q", *new(T), cfg.IDField)
+	}
+
+	res := &Resource[T]{
+		items:   map[string]T{},
+		nextID:  1,
+		idField: idField.Index,
+		idKind:  idField.Type.Kind(),
+		fm:      fm,
+	}
+
+	fakeDataFunc := fm.fakeDataFunc(cfg.endpointConfig(http.MethodGet, cfg.Path), fm.rand)
+	for i := 0; i < cfg.SeedItems; i++ {
+		item, err := getResponseData[T](fakeDataFunc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to register resource: %v", err)
+		}
+		// faked IDs may collide, so seeded items are always numbered by the resource
+		res.setID(item, res.newID())
+		res.store(deepCopy(*item))
+		res.initial = append(res.initial, *item)
+	}
+
+	itemPath := cfg.Path + "/{id}"
+	routes := []struct {
+		method  string
+		path    string
+		respond func(w http.ResponseWriter, r *http.Request, e *endpoint)
+	}{
+		{http.MethodPost, cfg.Path, res.create},
+		{http.MethodGet, cfg.Path, res.list},
+		{http.MethodGet, itemPath, res.get},
+		{http.MethodPut, itemPath, res.replace},
+		{http.MethodPatch, itemPath, res.patch},
+		{http.MethodDelete, itemPath, res.delete},
+	}
+	for _, route := range routes {
+		if err := fm.register(cfg.endpointConfig(route.method, route.path), route.respond); err != nil {
+			return nil, fmt.Errorf("failed to register resource: %v", err)
+		}
+	}
+
+	fm.mu.Lock()
+	fm.resetters = append(fm.resetters, res.Reset)
+	fm.mu.Unlock()
+
+	return res, nil
+}
+
+// Items returns the items of the resource in creation order
+func (res *Resource[T]) Items() []T {
+	res.mu.RLock()
+	defer res.mu.RUnlock()
+
+	items := make([]T, 0, len(res.order))
+	for _, id := range res.order {
+		items = append(items, res.items[id])
+	}
+	return items
+}
+
+// Get returns the item with the given ID
+func (res *Resource[T]) Get(id string) (T, bool) {
+	res.mu.RLock()
+	defer res.mu.RUnlock()
+
+	item, ok := res.items[id]
+	return item, ok
+}
+
+// Reset restores the resource to its seeded items
+func (res *Resource[T]) Reset() {
+	res.mu.Lock()
+	defer res.mu.Unlock()
+
+	res.items = map[string]T{}
+	res.order = nil
+	res.nextID = 1
+	for _, item := range res.initial {
+		res.storeLocked(deepCopy(item))
+	}
+}
+
+func (res *Resource[T]) create(w http.ResponseWriter, r *http.Request, e *endpoint) {
+	var item T
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		http.Error(w, fmt.Sprintf("Bad Request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	res.mu.Lock()
+	id := res.idOf(&item)
+	if id == "" {
+		id = res.newIDLocked()
+		res.setID(&item, id)
+	} else if _, exists := res.items[id]; exists {
+		res.mu.Unlock()
+		http.Error(w, "Conflict", http.StatusConflict)
+		return
+	}
+	res.storeLocked(item)
+	res.mu.Unlock()
+
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+id)
+	writeJSONStatus(w, http.StatusCreated, item)
+}
+
+func (res *Resource[T]) list(w http.ResponseWriter, r *http.Request, e *endpoint) {
+	writeJSON(w, res.Items())
+}
+
+func (res *Resource[T]) get(w http.ResponseWriter, r *http.Request, e *endpoint) {
+	item, ok := res.Get(r.PathValue("id"))
+	if !ok {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, item)
+}
+
+func (res *Resource[T]) replace(w http.ResponseWriter, r *http.Request, e *endpoint) {
+	var item T
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		http.Error(w, fmt.Sprintf("Bad Request: %v", err), http.StatusBadRequest)
+		return
+	}
+	res.update(w, r, func(*T) (T, error) { return item, nil })
+}
+
+func (res *Resource[T]) patch(w http.ResponseWriter, r *http.Request, e *endpoint) {
+	var patch json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		http.Error(w, fmt.Sprintf("Bad Request: %v", err), http.StatusBadRequest)
+		return
+	}
+	res.update(w, r, func(existing *T) (T, error) {
+		// fields absent from the patch keep their current value; the maps and slices of the
+		// existing item are copied so that a failed patch leaves it untouched
+		item := deepCopy(*existing)
+		err := json.Unmarshal(patch, &item)
+		return item, err
+	})
+}
+
+// update replaces the item named by the request path with the result of fn, keeping its ID. If fn
+// fails, the item is left as is and the request is answered with 400 Bad Request.
+func (res *Resource[T]) update(w http.ResponseWriter, r *http.Request, fn func(existing *T) (T, error)) {
+	id := r.PathValue("id")
+
+	res.mu.Lock()
+	existing, ok := res.items[id]
+	if !ok {
+		res.mu.Unlock()
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	item, err := fn(&existing)
+	if err != nil {
+		res.mu.Unlock()
+		http.Error(w, fmt.Sprintf("Bad Request: %v", err), http.StatusBadRequest)
+		return
+	}
+	res.setID(&item, id)
+	res.items[id] = item
+	res.mu.Unlock()
+
+	writeJSON(w, item)
+}
+
+func (res *Resource[T]) delete(w http.ResponseWriter, r *http.Request, e *endpoint) {
+	id := r.PathValue("id")
+
+	res.mu.Lock()
+	_, ok := res.items[id]
+	if ok {
+		delete(res.items, id)
+		for i, orderedID := range res.order {
+			if orderedID == id {
+				res.order = append(res.order[:i], res.order[i+1:]...)
+				break
+			}
+		}
+	}
+	res.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (res *Resource[T]) store(item T) {
+	res.mu.Lock()
+	defer res.mu.Unlock()
+	res.storeLocked(item)
+}
+
+func (res *Resource[T]) storeLocked(item T) {
+	id := res.idOf(&item)
+	if _, exists := res.items[id]; !exists {
+		res.order = append(res.order, id)
+	}
+	res.items[id] = item
+
+	// keep generated integer IDs above the ones chosen by clients
+	if n, err := strconv.ParseInt(id, 10, 64); err == nil && n >= res.nextID {
+		res.nextID = n + 1
+	}
+}
+
+func (res *Resource[T]) newID() string {
+	res.mu.Lock()
+	defer res.mu.Unlock()
+	return res.newIDLocked()
+}
+
+func (res *Resource[T]) newIDLocked() string {
+	if res.idKind == reflect.String {
+		return randomString(res.fm.rand, "uuid")
+	}
+	id := strconv.FormatInt(res.nextID, 10)
+	res.nextID++
+	return id
+}
+
+// idOf returns the ID of item, or "" if it has none
+func (res *Resource[T]) idOf(item *T) string {
+	field := reflect.ValueOf(item).Elem().FieldByIndex(res.idField)
+	if field.IsZero() {
+		return ""
+	}
+	return fmt.Sprint(field.Interface())
+}
+
+func (res *Resource[T]) setID(item *T, id string) {
+	// the ID field was checked to be a string or integer, and IDs are either generated or parsed from it
+	setFromString(reflect.ValueOf(item).Elem().FieldByIndex(res.idField), id)
+}
+
+// findIDField returns the string or integer field of struct type t with the given JSON or Go name
+func findIDField(t reflect.Type, name string) (reflect.StructField, bool) {
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		if _, ok := fieldParamName(field, map[string]string{name: ""}); !ok {
+			continue
+		}
+		switch field.Type.Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// deepCopy returns a copy of v sharing none of its maps, slices and pointers, so that stored items
+// and the seeded ones they are restored from cannot change each other
+func deepCopy[T any](v T) T {
+	return deepCopyValue(reflect.ValueOf(&v).Elem()).Interface().(T)
+}
+
+func deepCopyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(deepCopyValue(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(deepCopyValue(v.Elem()))
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			c.SetMapIndex(iter.Key(), deepCopyValue(iter.Value()))
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+		return c
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(deepCopyValue(v.Index(i)))
+		}
+		return c
+	case reflect.Struct:
+		// unexported fields, which cannot be set, are copied as they are
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(deepCopyValue(v.Field(i)))
+			}
+		}
+		return c
+	default:
+		return v
+	}
+}
@@ -0,0 +1,180 @@
+package fauxmux
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegisterResource(t *testing.T) {
+	mux := NewMux()
+
+	res, err := RegisterResource[User](mux, ResourceConfig{
+		Path:      "/users",
+		SeedItems: 2,
+	})
+	if err != nil {
+		t.Fatalf("failed to register resource: %v", err)
+	}
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.Mux().ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder, v any) {
+		t.Helper()
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("failed to unmarshal response body %q: %v", w.Body.String(), err)
+		}
+	}
+
+	var users []User
+	decode(do("GET", "/users", ""), &users)
+	if len(users) != 2 || users[0].ID != 1 || users[1].ID != 2 {
+		t.Fatalf("expected seeded users 1 and 2 but got %+v", users)
+	}
+
+	w := do("POST", "/users", `{"name": "Jane", "email": "jane@testing.com"}`)
+	if w.Code != http.StatusCreated || w.Header().Get("Location") != "/users/3" {
+		t.Fatalf("expected 201 with location /users/3 but got %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	var user User
+	decode(do("GET", "/users/3", ""), &user)
+	if user != (User{ID: 3, Name: "Jane", Email: "jane@testing.com"}) {
+		t.Fatalf("expected created user but got %+v", user)
+	}
+
+	decode(do("PATCH", "/users/3", `{"email": "jane@example.com"}`), &user)
+	if user != (User{ID: 3, Name: "Jane", Email: "jane@example.com"}) {
+		t.Fatalf("expected patched user but got %+v", user)
+	}
+
+	decode(do("PUT", "/users/3", `{"id": 99, "name": "Janet"}`), &user)
+	if user != (User{ID: 3, Name: "Janet"}) {
+		t.Fatalf("expected replaced user but got %+v", user)
+	}
+
+	if w := do("POST", "/users", `{"id": 1}`); w.Code != http.StatusConflict {
+		t.Fatalf("expected status code %d but got %d", http.StatusConflict, w.Code)
+	}
+
+	if w := do("POST", "/users", `{`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected status code %d but got %d", http.StatusBadRequest, w.Code)
+	}
+
+	if w := do("DELETE", "/users/3", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected status code %d but got %d", http.StatusNoContent, w.Code)
+	}
+
+	for _, method := range []string{"GET", "PATCH", "PUT", "DELETE"} {
+		if w := do(method, "/users/3", `{}`); w.Code != http.StatusNotFound {
+			t.Fatalf("expected status code %d for %s but got %d", http.StatusNotFound, method, w.Code)
+		}
+	}
+
+	do("POST", "/users", `{"name": "John"}`)
+	res.Reset()
+	if items := res.Items(); len(items) != 2 {
+		t.Fatalf("expected 2 users after reset but got %+v", items)
+	}
+}
+
+func TestRegisterResource_StringID(t *testing.T) {
+	type Order struct {
+		Ref   string `json:"ref"`
+		Total int    `json:"total"`
+	}
+
+	mux := NewMux(WithSeed(1))
+
+	res, err := RegisterResource[Order](mux, ResourceConfig{
+		Path:    "/orders",
+		IDField: "ref",
+	})
+	if err != nil {
+		t.Fatalf("failed to register resource: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("POST", "/orders", strings.NewReader(`{"total": 10}`)))
+
+	var order Order
+	if err := json.Unmarshal(w.Body.Bytes(), &order); err != nil {
+		t.Fatalf("failed to unmarshal response body: %v", err)
+	}
+	if _, ok := res.Get(order.Ref); !ok || len(order.Ref) != 36 {
+		t.Fatalf("expected order with a generated uuid but got %+v", order)
+	}
+}
+
+func TestRegisterResource_InvalidConfig(t *testing.T) {
+	if _, err := RegisterResource[User](NewMux(), ResourceConfig{Path: "/users", IDField: "uuid"}); err == nil {
+		t.Errorf("expected missing ID field to fail registration")
+	}
+
+	if _, err := RegisterResource[User](NewMux(), ResourceConfig{Path: "/users/", SeedItems: 1}); err == nil {
+		t.Errorf("expected trailing slash to fail registration")
+	}
+
+	if _, err := RegisterResource[User](NewMux(), ResourceConfig{Path: "/users", SeedItems: -1}); err == nil {
+		t.Errorf("expected negative seed items to fail registration")
+	}
+}
+
+type taggedThing struct {
+	ID   int               `json:"id"`
+	N    int               `json:"n"`
+	Note string            `json:"note"`
+	Tags map[string]string `json:"tags"`
+}
+
+func TestRegisterResource_Patch(t *testing.T) {
+	mux := NewMux()
+	res, err := RegisterResource[taggedThing](mux, ResourceConfig{
+		Path:      "/things",
+		SeedItems: 1,
+		FakeDataFunc: func(v interface{}) error {
+			*v.(*taggedThing) = taggedThing{N: 1, Note: "seeded", Tags: map[string]string{"color": "red"}}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register resource: %v", err)
+	}
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.Mux().ServeHTTP(w, httptest.NewRequest("PATCH", "/things/1", strings.NewReader(body)))
+		return w
+	}
+
+	if w := patch(`{"note": "changed", "n": "notanint"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status code %d but got %d", http.StatusBadRequest, w.Code)
+	}
+	if thing, _ := res.Get("1"); thing.Note != "seeded" {
+		t.Errorf("expected a failed patch to leave the item untouched but got %+v", thing)
+	}
+
+	if w := patch(`{"tags": {"injected": "yes"}}`); w.Code != http.StatusOK {
+		t.Fatalf("expected status code %d but got %d", http.StatusOK, w.Code)
+	}
+	if thing, _ := res.Get("1"); thing.Tags["injected"] != "yes" || thing.Tags["color"] != "red" {
+		t.Fatalf("expected the patch to be merged into the tags but got %+v", thing)
+	}
+
+	mux.Reset()
+	if thing, _ := res.Get("1"); len(thing.Tags) != 1 || thing.Tags["color"] != "red" {
+		t.Errorf("expected reset to restore the seeded tags but got %+v", thing.Tags)
+	}
+
+	// items restored by a reset do not share the seeded ones either
+	patch(`{"tags": {"again": "yes"}}`)
+	mux.Reset()
+	if thing, _ := res.Get("1"); len(thing.Tags) != 1 {
+		t.Errorf("expected reset to restore the seeded tags but got %+v", thing.Tags)
+	}
+}
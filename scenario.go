@@ -0,0 +1,137 @@
+package fauxmux
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ScenarioStarted is the state every scenario starts in
+const ScenarioStarted = "Started"
+
+// ScenarioConfig makes an endpoint take part in a named scenario, a state machine shared by the
+// endpoints of a Mux. Several endpoints with the same method and path may be registered, one per
+// required state, to answer differently as the scenario progresses.
+type ScenarioConfig struct {
+	Name string
+	// RequiredState, when set, restricts the endpoint to the requests received while the scenario
+	// is in that state. An endpoint without one answers when no other endpoint matches.
+	RequiredState string
+	// NewState, when set, is the state the scenario moves to when the endpoint responds. Requests
+	// that are rate limited, cancelled, faulted or answered with an error response leave the
+	// scenario in its state, so that a client retrying them gets the same endpoint.
+	NewState string
+}
+
+func (s ScenarioConfig) Validate() error {
+	if s.Name == "" {
+		return fieldError("Name", fmt.Errorf("name cannot be empty"))
+	}
+
+	if s.RequiredState == "" && s.NewState == "" {
+		return fieldError("NewState", fmt.Errorf("required state or new state must be set"))
+	}
+
+	return nil
+}
+
+// scenarioKey identifies the endpoints of a method and path that replace each other on registration
+func scenarioKey(s *ScenarioConfig) string {
+	if s == nil || s.RequiredState == "" {
+		return ""
+	}
+	return s.Name + "\x00" + s.RequiredState
+}
+
+// scenarios holds the state of the scenarios of a Mux
+type scenarios struct {
+	mu     sync.Mutex
+	states map[string]string
+}
+
+func (s *scenarios) stateLocked(name string) string {
+	if state, ok := s.states[name]; ok {
+		return state
+	}
+	return ScenarioStarted
+}
+
+func (s *scenarios) state(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stateLocked(name)
+}
+
+func (s *scenarios) set(name, state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.states == nil {
+		s.states = map[string]string{}
+	}
+	s.states[name] = state
+}
+
+func (s *scenarios) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states = nil
+}
+
+// match returns the endpoint answering a request given the current scenario states, preferring an
+// endpoint requiring the current state of its scenario over one requiring none
+func (s *scenarios) match(endpoints []*endpoint) (*endpoint, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched *endpoint
+	for _, e := range endpoints {
+		scenario := e.cfg.Scenario
+		if scenario == nil || scenario.RequiredState == "" {
+			if matched == nil {
+				matched = e
+			}
+			continue
+		}
+		if s.stateLocked(scenario.Name) == scenario.RequiredState {
+			matched = e
+			break
+		}
+	}
+	if matched == nil {
+		return nil, false
+	}
+	return matched, true
+}
+
+// advance moves the scenario of e to its new state once e responds, unless a concurrent request has
+// moved it out of the state e requires in the meantime
+func (s *scenarios) advance(e *endpoint) {
+	scenario := e.cfg.Scenario
+	if scenario == nil || scenario.NewState == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if scenario.RequiredState != "" && s.stateLocked(scenario.Name) != scenario.RequiredState {
+		return
+	}
+	if s.states == nil {
+		s.states = map[string]string{}
+	}
+	s.states[scenario.Name] = scenario.NewState
+}
+
+// ScenarioState returns the current state of the scenario name
+func (fm *Mux) ScenarioState(name string) string {
+	return fm.scenarios.state(name)
+}
+
+// SetScenarioState moves the scenario name to state
+func (fm *Mux) SetScenarioState(name, state string) {
+	fm.scenarios.set(name, state)
+}
+
+// ResetScenarios moves every scenario back to ScenarioStarted
+func (fm *Mux) ResetScenarios() {
+	fm.scenarios.reset()
+}
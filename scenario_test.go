@@ -0,0 +1,157 @@
+package fauxmux
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScenarioConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ScenarioConfig
+		wantErr bool
+	}{
+		{name: "required state", config: ScenarioConfig{Name: "order", RequiredState: "shipped"}},
+		{name: "new state", config: ScenarioConfig{Name: "order", NewState: "shipped"}},
+		{name: "no name", config: ScenarioConfig{NewState: "shipped"}, wantErr: true},
+		{name: "no state", config: ScenarioConfig{Name: "order"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+const testScenarioConfig = `endpoints:
+  - method: GET
+    path: /orders/1
+    response_format: json
+    response: {status: pending}
+  - method: GET
+    path: /orders/1
+    response_format: json
+    response: {status: shipped}
+    scenario: {name: order, required_state: shipped}
+  - method: POST
+    path: /orders/1/ship
+    response_format: json
+    response: {}
+    scenario: {name: order, required_state: Started, new_state: shipped}
+`
+
+func TestScenario(t *testing.T) {
+	mux := NewMux()
+	if err := LoadConfig(mux, strings.NewReader(testScenarioConfig)); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		mux.Mux().ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	if w := serve("GET", "/orders/1"); !strings.Contains(w.Body.String(), "pending") {
+		t.Fatalf("expected a pending order but got %q", w.Body.String())
+	}
+
+	if w := serve("POST", "/orders/1/ship"); w.Code != http.StatusOK {
+		t.Fatalf("expected status code %d but got %d", http.StatusOK, w.Code)
+	}
+	if state := mux.ScenarioState("order"); state != "shipped" {
+		t.Fatalf("expected the order to be shipped but it is %q", state)
+	}
+
+	if w := serve("GET", "/orders/1"); !strings.Contains(w.Body.String(), "shipped") {
+		t.Fatalf("expected a shipped order but got %q", w.Body.String())
+	}
+
+	// the order can only be shipped once
+	if w := serve("POST", "/orders/1/ship"); w.Code != http.StatusNotFound {
+		t.Errorf("expected status code %d but got %d", http.StatusNotFound, w.Code)
+	}
+	mux.Journal().AssertCalled(t, "POST", "/orders/1/ship", 1, WithOutcome(OutcomeNoRoute))
+
+	mux.ResetScenarios()
+	if w := serve("GET", "/orders/1"); !strings.Contains(w.Body.String(), "pending") {
+		t.Errorf("expected a pending order after reset but got %q", w.Body.String())
+	}
+
+	mux.SetScenarioState("order", "shipped")
+	if w := serve("GET", "/orders/1"); !strings.Contains(w.Body.String(), "shipped") {
+		t.Errorf("expected a shipped order but got %q", w.Body.String())
+	}
+}
+
+func TestScenario_AdvancesOnResponse(t *testing.T) {
+	mux := NewMux()
+	unavailable := &ErrorResponse{StatusCode: http.StatusServiceUnavailable, Response: "unavailable", ResponseFormat: Text}
+	err := RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "POST",
+		Path:           "/orders/1/ship",
+		ResponseFormat: JSON,
+		Scenario:       &ScenarioConfig{Name: "order", RequiredState: ScenarioStarted, NewState: "shipped"},
+		RateLimit:      &RateLimitConfig{Limit: 3, Window: time.Hour},
+		Script: &ScriptConfig{Steps: []ScriptStep{
+			{Error: unavailable},
+			{Fault: FaultMalformedJSON},
+			{Latency: FixedLatency(time.Second)},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, r := range []*http.Request{
+		httptest.NewRequest("POST", "/orders/1/ship", nil),
+		httptest.NewRequest("POST", "/orders/1/ship", nil),
+		httptest.NewRequest("POST", "/orders/1/ship", nil).WithContext(ctx),
+		httptest.NewRequest("POST", "/orders/1/ship", nil),
+	} {
+		mux.Mux().ServeHTTP(httptest.NewRecorder(), r)
+		if state := mux.ScenarioState("order"); state != ScenarioStarted {
+			t.Fatalf("expected the order to stay %q after a failed request but it is %q", ScenarioStarted, state)
+		}
+	}
+
+	journal := mux.Journal()
+	for _, outcome := range []Outcome{OutcomeError, OutcomeFault, OutcomeCancelled, OutcomeRateLimited} {
+		journal.AssertCalled(t, "POST", "/orders/1/ship", 1, WithOutcome(outcome))
+	}
+}
+
+func TestScenario_ReplacesSameState(t *testing.T) {
+	mux := NewMux()
+	for _, status := range []string{"first", "second"} {
+		response := status
+		err := RegisterEndpoint[string](mux, EndpointConfig{
+			Method:         "GET",
+			Path:           "/orders/1",
+			ResponseFormat: Text,
+			Scenario:       &ScenarioConfig{Name: "order", RequiredState: ScenarioStarted},
+			FakeDataFunc: func(v interface{}) error {
+				*v.(*string) = response
+				return nil
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to register endpoint: %v", err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/orders/1", nil))
+	if w.Body.String() != "second" {
+		t.Errorf("expected the endpoint registered last to answer but got %q", w.Body.String())
+	}
+}
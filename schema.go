@@ -0,0 +1,137 @@
+package fauxmux
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"time"
+)
+
+// schemaSpec describes the shape of a payload generated for an endpoint of a declarative configuration file
+type schemaSpec struct {
+	Type       string                 `yaml:"type"`
+	Format     string                 `yaml:"format"`
+	Enum       []any                  `yaml:"enum"`
+	Min        *float64               `yaml:"min"`
+	Max        *float64               `yaml:"max"`
+	MinItems   int                    `yaml:"min_items"`
+	MaxItems   int                    `yaml:"max_items"`
+	Items      *schemaSpec            `yaml:"items"`
+	Properties map[string]*schemaSpec `yaml:"properties"`
+}
+
+var schemaTypes = []string{"object", "array", "string", "integer", "number", "boolean"}
+
+func (s *schemaSpec) Validate() error {
+	if len(s.Enum) > 0 {
+		return nil
+	}
+
+	if !slices.Contains(schemaTypes, s.Type) {
+		return fieldError("type", fmt.Errorf("invalid schema type %q", s.Type))
+	}
+
+	if s.Min != nil && s.Max != nil && *s.Max < *s.Min {
+		return fieldError("max", fmt.Errorf("max cannot be less than min"))
+	}
+
+	switch s.Type {
+	case "object":
+		for name, property := range s.Properties {
+			if property == nil {
+				return fieldError("properties."+name, fmt.Errorf("property cannot be empty"))
+			}
+			if err := property.Validate(); err != nil {
+				return fieldError("properties."+name, err)
+			}
+		}
+	case "array":
+		if s.Items == nil {
+			return fieldError("items", fmt.Errorf("items cannot be empty"))
+		}
+		if err := (ListResponseConfig{MinItems: s.MinItems, MaxItems: s.MaxItems}).Validate(); err != nil {
+			return err
+		}
+		if err := s.Items.Validate(); err != nil {
+			return fieldError("items", err)
+		}
+	case "string":
+		if _, ok := fakeStringKinds[s.Format]; !ok && s.Format != "" && s.Format != "date-time" {
+			return fieldError("format", fmt.Errorf("invalid string format %q", s.Format))
+		}
+	}
+
+	return nil
+}
+
+// generate returns a random value matching the schema
+func (s *schemaSpec) generate(rng *rand.Rand) any {
+	if len(s.Enum) > 0 {
+		return s.Enum[rng.Intn(len(s.Enum))]
+	}
+
+	switch s.Type {
+	case "object":
+		// properties are visited in a fixed order so that seeded sources generate the same objects
+		names := make([]string, 0, len(s.Properties))
+		for name := range s.Properties {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		object := make(map[string]any, len(s.Properties))
+		for _, name := range names {
+			object[name] = s.Properties[name].generate(rng)
+		}
+		return object
+	case "array":
+		length := s.MinItems
+		if s.MaxItems > s.MinItems {
+			length += rng.Intn(s.MaxItems - s.MinItems + 1)
+		}
+		array := make([]any, 0, length)
+		for i := 0; i < length; i++ {
+			array = append(array, s.Items.generate(rng))
+		}
+		return array
+	case "integer":
+		low, high := s.bounds(0, 1000)
+		return int64(low) + rng.Int63n(int64(high)-int64(low)+1)
+	case "number":
+		low, high := s.bounds(0, 1000)
+		return low + rng.Float64()*(high-low)
+	case "boolean":
+		return rng.Intn(2) == 1
+	default:
+		return randomString(rng, s.Format)
+	}
+}
+
+func (s *schemaSpec) bounds(defaultMin, defaultMax float64) (float64, float64) {
+	low, high := defaultMin, defaultMax
+	if s.Min != nil {
+		low = *s.Min
+		if s.Max == nil && high < low {
+			high = low + defaultMax
+		}
+	}
+	if s.Max != nil {
+		high = *s.Max
+		if s.Min == nil && low > high {
+			low = high - defaultMax
+		}
+	}
+	return low, high
+}
+
+// randomString returns a random string in the given format
+func randomString(rng *rand.Rand, format string) string {
+	switch format {
+	case "":
+		return fakeStringKinds["word"](rng)
+	case "date-time":
+		return time.Unix(rng.Int63n(2_000_000_000), 0).UTC().Format(time.RFC3339)
+	default:
+		// Validate has already checked the format
+		return fakeStringKinds[format](rng)
+	}
+}
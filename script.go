@@ -0,0 +1,113 @@
+package fauxmux
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ScriptEnd is what a script does once every step has been played
+type ScriptEnd string
+
+const (
+	// ScriptFallBack falls back to the random latency, faults and errors of the endpoint
+	ScriptFallBack ScriptEnd = "fall_back"
+	// ScriptLoop plays the steps again from the first
+	ScriptLoop ScriptEnd = "loop"
+	// ScriptRepeatLast plays the last step for every later request
+	ScriptRepeatLast ScriptEnd = "repeat_last"
+)
+
+// ScriptStep is the scripted outcome of a request: a fault if Fault is set, an error response if
+// Error is set, and a successful response otherwise
+type ScriptStep struct {
+	// Latency, when set, replaces the latency distribution of the endpoint for the request
+	Latency LatencyModel
+	Error   *ErrorResponse
+	Fault   FaultKind
+}
+
+func (s ScriptStep) Validate() error {
+	if s.Latency != nil {
+		if err := s.Latency.Validate(); err != nil {
+			return fieldError("Latency", err)
+		}
+	}
+
+	if s.Error != nil {
+		if s.Fault != "" {
+			return fieldError("Fault", fmt.Errorf("fault and error cannot both be set"))
+		}
+
+		if err := s.Error.Validate(); err != nil {
+			return fieldError("Error", err)
+		}
+	}
+
+	if s.Fault != "" && !isFaultKind(s.Fault) {
+		return fieldError("Fault", fmt.Errorf("invalid fault kind %q", s.Fault))
+	}
+
+	return nil
+}
+
+// ScriptConfig answers the requests to an endpoint with an ordered sequence of outcomes instead of
+// random ones, e.g. two 503 errors followed by a successful response
+type ScriptConfig struct {
+	Steps []ScriptStep
+	// OnExhausted defaults to ScriptFallBack
+	OnExhausted ScriptEnd
+}
+
+func (s ScriptConfig) Validate() error {
+	if len(s.Steps) == 0 {
+		return fieldError("Steps", fmt.Errorf("steps cannot be empty"))
+	}
+
+	for i, step := range s.Steps {
+		if err := step.Validate(); err != nil {
+			return fieldError(fmt.Sprintf("Steps[%d]", i), err)
+		}
+	}
+
+	switch s.OnExhausted {
+	case "", ScriptFallBack, ScriptLoop, ScriptRepeatLast:
+	default:
+		return fieldError("OnExhausted", fmt.Errorf("invalid script end %q", s.OnExhausted))
+	}
+
+	return nil
+}
+
+// script plays the steps of a ScriptConfig, one per request
+type script struct {
+	cfg  ScriptConfig
+	mu   sync.Mutex
+	next int
+}
+
+// step returns the step of the next request, or false once a falling back script is exhausted
+func (s *script) step() (ScriptStep, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.next
+	if i >= len(s.cfg.Steps) {
+		switch s.cfg.OnExhausted {
+		case ScriptLoop:
+			i = 0
+		case ScriptRepeatLast:
+			return s.cfg.Steps[len(s.cfg.Steps)-1], true
+		default:
+			return ScriptStep{}, false
+		}
+	}
+	s.next = i + 1
+	return s.cfg.Steps[i], true
+}
+
+// reset restarts the script from its first step
+func (s *script) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next = 0
+}
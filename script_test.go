@@ -0,0 +1,161 @@
+package fauxmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScriptConfig_Validate(t *testing.T) {
+	unavailable := &ErrorResponse{StatusCode: 503, Response: "unavailable", ResponseFormat: Text}
+
+	tests := []struct {
+		name    string
+		config  ScriptConfig
+		wantErr bool
+	}{
+		{name: "steps", config: ScriptConfig{Steps: []ScriptStep{{Error: unavailable}, {Fault: FaultHang}, {Latency: FixedLatency(time.Millisecond)}}}},
+		{name: "no steps", config: ScriptConfig{OnExhausted: ScriptLoop}, wantErr: true},
+		{name: "error and fault", config: ScriptConfig{Steps: []ScriptStep{{Error: unavailable, Fault: FaultHang}}}, wantErr: true},
+		{name: "invalid error", config: ScriptConfig{Steps: []ScriptStep{{Error: &ErrorResponse{StatusCode: 503}}}}, wantErr: true},
+		{name: "invalid fault", config: ScriptConfig{Steps: []ScriptStep{{Fault: "explode"}}}, wantErr: true},
+		{name: "invalid latency", config: ScriptConfig{Steps: []ScriptStep{{Latency: FixedLatency(-1)}}}, wantErr: true},
+		{name: "invalid end", config: ScriptConfig{Steps: []ScriptStep{{}}, OnExhausted: "stop"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScript(t *testing.T) {
+	unavailable := &ErrorResponse{StatusCode: 503, Response: "unavailable", ResponseFormat: Text}
+
+	tests := []struct {
+		name        string
+		onExhausted ScriptEnd
+		wantCodes   []int
+	}{
+		// the endpoint always errors with 500 when not scripted
+		{name: "fall back", wantCodes: []int{503, 503, 200, 500, 500}},
+		{name: "loop", onExhausted: ScriptLoop, wantCodes: []int{503, 503, 200, 503, 503}},
+		{name: "repeat last", onExhausted: ScriptRepeatLast, wantCodes: []int{503, 503, 200, 200, 200}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := NewMux()
+			err := RegisterEndpoint[string](mux, EndpointConfig{
+				Method:         "GET",
+				Path:           "/flaky",
+				ResponseFormat: Text,
+				ErrorResponseConfig: &ErrorResponseConfig{
+					Frequency: 1,
+					Responses: []ErrorResponse{{StatusCode: 500, Response: "oops", ResponseFormat: Text}},
+				},
+				Script: &ScriptConfig{
+					Steps:       []ScriptStep{{Error: unavailable}, {Error: unavailable}, {}},
+					OnExhausted: tt.onExhausted,
+				},
+				FakeDataFunc: func(v interface{}) error {
+					*v.(*string) = "ok"
+					return nil
+				},
+			})
+			if err != nil {
+				t.Fatalf("failed to register endpoint: %v", err)
+			}
+
+			codes := make([]int, 0, len(tt.wantCodes))
+			for range tt.wantCodes {
+				w := httptest.NewRecorder()
+				mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/flaky", nil))
+				codes = append(codes, w.Code)
+			}
+			if !slices.Equal(codes, tt.wantCodes) {
+				t.Errorf("expected status codes %v but got %v", tt.wantCodes, codes)
+			}
+
+			mux.ResetScripts()
+			w := httptest.NewRecorder()
+			mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/flaky", nil))
+			if w.Code != 503 {
+				t.Errorf("expected the reset script to start over but got status code %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestScript_FaultAndLatency(t *testing.T) {
+	mux := NewMux()
+	err := RegisterEndpoint[string](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/flaky",
+		ResponseFormat: Text,
+		Script: &ScriptConfig{Steps: []ScriptStep{
+			{Fault: FaultCloseConnection},
+			{Latency: FixedLatency(50 * time.Millisecond)},
+		}},
+		FakeDataFunc: func(v interface{}) error {
+			*v.(*string) = "ok"
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	server := httptest.NewServer(mux.Mux())
+	defer server.Close()
+
+	if _, err := http.Get(server.URL + "/flaky"); err == nil {
+		t.Fatal("expected the connection to be closed")
+	}
+
+	start := time.Now()
+	resp, err := http.Get(server.URL + "/flaky")
+	if err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); resp.StatusCode != http.StatusOK || elapsed < 50*time.Millisecond {
+		t.Errorf("expected a response after 50ms but got status code %d after %v", resp.StatusCode, elapsed)
+	}
+
+	mux.Journal().AssertCalled(t, "GET", "/flaky", 1, WithOutcome(OutcomeFault))
+}
+
+func TestLoadConfig_Script(t *testing.T) {
+	config := `endpoints:
+  - method: GET
+    path: /flaky
+    response_format: text
+    response: ok
+    script:
+      on_exhausted: repeat_last
+      steps:
+        - error: {status_code: 503, response_format: text, response: unavailable}
+        - {}
+`
+	mux := NewMux()
+	if err := LoadConfig(mux, strings.NewReader(config)); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	codes := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/flaky", nil))
+		codes = append(codes, w.Code)
+	}
+	if want := []int{503, 200, 200}; !slices.Equal(codes, want) {
+		t.Errorf("expected status codes %v but got %v", want, codes)
+	}
+}
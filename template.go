@@ -0,0 +1,98 @@
+package fauxmux
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// TemplateData is the data a response template is executed with. The embedded Request gives access
+// to the path parameters, query, headers and body of the request:
+//
+//	{"id": {{json .PathParams.id}}, "q": {{json (.Query.Get "q")}}, "name": {{json .JSON.name}}}
+type TemplateData struct {
+	*Request
+	// Count is the number of requests the endpoint has answered with the template, this one included
+	Count int64
+	// Now is the time the template is executed at
+	Now time.Time
+}
+
+// templateFuncs returns the functions available to response templates, drawing from rng:
+//
+//	json       the JSON encoding of a value
+//	fake       a fake string of a kind, e.g. {{fake "email"}} or {{fake "uuid"}}, see FakeData
+//	fakeInt    a fake integer within min and max, inclusive
+//	fakeFloat  a fake float within min and max
+//	oneOf      one of its arguments
+func templateFuncs(rng *rand.Rand) template.FuncMap {
+	return template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"fake": func(kind string) (string, error) {
+			fake, ok := fakeStringKinds[kind]
+			if !ok {
+				return "", fmt.Errorf("invalid fake data kind %q", kind)
+			}
+			return fake(rng), nil
+		},
+		"fakeInt": func(min, max int) (int, error) {
+			if max < min {
+				return 0, fmt.Errorf("max cannot be less than min")
+			}
+			return min + rng.Intn(max-min+1), nil
+		},
+		"fakeFloat": func(min, max float64) float64 {
+			return min + rng.Float64()*(max-min)
+		},
+		"oneOf": func(values ...any) (any, error) {
+			if len(values) == 0 {
+				return nil, fmt.Errorf("oneOf requires at least one value")
+			}
+			return values[rng.Intn(len(values))], nil
+		},
+	}
+}
+
+// parseTemplate parses the response template text, whose functions draw from rng
+func parseTemplate(text string, rng *rand.Rand) (*template.Template, error) {
+	return template.New("response").Funcs(templateFuncs(rng)).Parse(text)
+}
+
+// responseTemplate is the parsed template of an endpoint and the count of the requests it answered
+type responseTemplate struct {
+	tmpl  *template.Template
+	count atomic.Int64
+}
+
+// respondWithTemplate writes a successful response whose body is the endpoint's template executed
+// for r, with the content type of the endpoint's response format
+func respondWithTemplate(w http.ResponseWriter, r *http.Request, e *endpoint) {
+	req, err := newRequest(r, e.paramNames)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Bad Request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var body bytes.Buffer
+	data := TemplateData{Request: req, Count: e.template.count.Add(1), Now: time.Now()}
+	if err := e.template.tmpl.Execute(&body, data); err != nil {
+		http.Error(w, fmt.Sprintf("Internal Server Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Validate has already checked the format
+	enc, _ := lookupEncoder(e.cfg.ResponseFormat)
+	w.Header().Set("Content-Type", enc.ContentType())
+	w.Header().Set("Content-Length", strconv.Itoa(body.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
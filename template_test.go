@@ -0,0 +1,104 @@
+package fauxmux
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testTemplateConfig = `endpoints:
+  - method: POST
+    path: /orders/{id}
+    response_format: json
+    template: |
+      {"id": {{json .PathParams.id}}, "q": {{json (.Query.Get "q")}}, "trace": {{json (.Header.Get "X-Trace")}}, "item": {{json .JSON.item}}, "count": {{.Count}}, "year": {{.Now.Year}}, "ref": {{json (fake "uuid")}}, "n": {{fakeInt 1 3}}, "status": {{json (oneOf "new" "paid")}}}
+`
+
+func TestTemplate(t *testing.T) {
+	mux := NewMux(WithSeed(1))
+	if err := LoadConfig(mux, strings.NewReader(testTemplateConfig)); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	serve := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "/orders/42?q=shoes", strings.NewReader(`{"item": "boots"}`))
+		r.Header.Set("X-Trace", "abc")
+		w := httptest.NewRecorder()
+		mux.Mux().ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve()
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected a JSON response but got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	pattern := `^\{"id": "42", "q": "shoes", "trace": "abc", "item": "boots", "count": 1, "year": \d{4}, "ref": "[0-9a-f-]{36}", "n": [1-3], "status": "(new|paid)"\}\n$`
+	if !regexp.MustCompile(pattern).MatchString(w.Body.String()) {
+		t.Fatalf("expected the rendered template but got %q", w.Body.String())
+	}
+
+	if w := serve(); !strings.Contains(w.Body.String(), `"count": 2`) {
+		t.Errorf("expected the second request to be counted but got %q", w.Body.String())
+	}
+	mux.Reset()
+	if w := serve(); !strings.Contains(w.Body.String(), `"count": 1`) {
+		t.Errorf("expected the count to restart after reset but got %q", w.Body.String())
+	}
+}
+
+func TestTemplate_Concurrent(t *testing.T) {
+	mux := NewMux(WithSeed(1))
+	if err := LoadConfig(mux, strings.NewReader(testTemplateConfig)); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	// run with -race: the template functions share the random source of the Mux
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				w := httptest.NewRecorder()
+				mux.Mux().ServeHTTP(w, httptest.NewRequest("POST", "/orders/42", strings.NewReader(`{}`)))
+				if w.Code != http.StatusOK {
+					t.Errorf("expected status code %d but got %d", http.StatusOK, w.Code)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTemplate_Errors(t *testing.T) {
+	mux := NewMux()
+
+	err := RegisterEndpoint[any](mux, EndpointConfig{
+		Method:             "GET",
+		Path:               "/users",
+		ResponseFormat:     JSON,
+		Template:           "[]",
+		ListResponseConfig: &ListResponseConfig{MaxItems: 1},
+	})
+	if err == nil {
+		t.Errorf("expected a template combined with a list to be rejected")
+	}
+
+	err = RegisterEndpoint[any](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/users",
+		ResponseFormat: Text,
+		Template:       `{{fake "nickname"}}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/users", nil))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "nickname") {
+		t.Errorf("expected a failing template to answer %d but got %d %q", http.StatusInternalServerError, w.Code, w.Body.String())
+	}
+}
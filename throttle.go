@@ -0,0 +1,146 @@
+package fauxmux
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// defaultChunkSize is the chunk size of throttled bodies limited by ChunkDelay only
+const defaultChunkSize = 1024
+
+// ThrottleConfig streams response bodies slowly, in chunks flushed to the client one at a time
+type ThrottleConfig struct {
+	// BytesPerSecond limits the bandwidth of the response body
+	BytesPerSecond int
+	// ChunkSize is the number of bytes written at a time. It defaults to a tenth of
+	// BytesPerSecond, or 1024 bytes if BytesPerSecond is not set.
+	ChunkSize int
+	// ChunkDelay is an additional pause between chunks
+	ChunkDelay time.Duration
+}
+
+func (t ThrottleConfig) Validate() error {
+	if t.BytesPerSecond < 0 {
+		return fieldError("BytesPerSecond", fmt.Errorf("bytes per second cannot be negative"))
+	}
+
+	if t.ChunkSize < 0 {
+		return fieldError("ChunkSize", fmt.Errorf("chunk size cannot be negative"))
+	}
+
+	if t.ChunkDelay < 0 {
+		return fieldError("ChunkDelay", fmt.Errorf("chunk delay cannot be negative"))
+	}
+
+	if t.BytesPerSecond == 0 && t.ChunkDelay == 0 {
+		return fieldError("BytesPerSecond", fmt.Errorf("bytes per second or chunk delay must be set"))
+	}
+
+	return nil
+}
+
+func (t ThrottleConfig) chunkSize() int {
+	switch {
+	case t.ChunkSize > 0:
+		return t.ChunkSize
+	case t.BytesPerSecond > 0:
+		return max(1, t.BytesPerSecond/10)
+	default:
+		return defaultChunkSize
+	}
+}
+
+// delay returns the pause owed after writing a chunk of n bytes
+func (t ThrottleConfig) delay(n int) time.Duration {
+	d := t.ChunkDelay
+	if t.BytesPerSecond > 0 {
+		d += time.Duration(n) * time.Second / time.Duration(t.BytesPerSecond)
+	}
+	return d
+}
+
+// slowWriter delays the response body by bodyDelay once the headers have been sent, then writes it
+// in throttled chunks if throttle is set, each followed by the pause it owes. A request cancelled by
+// its client while waiting is abandoned and recorded as such.
+type slowWriter struct {
+	http.ResponseWriter
+	ctx       context.Context
+	record    *RecordedRequest
+	bodyDelay time.Duration
+	throttle  *ThrottleConfig
+	// multiplier scales the throttling pauses, like the latency of the Mux
+	multiplier float64
+	started    bool
+	// owed is the pause owed for the last chunk written
+	owed time.Duration
+	err  error
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	rc := http.NewResponseController(w.ResponseWriter)
+	if !w.started {
+		w.started = true
+		if w.bodyDelay > 0 {
+			// send the headers, implicitly 200 OK if none were written, ahead of the body
+			rc.Flush()
+			w.owed = w.bodyDelay
+		}
+	}
+
+	if w.throttle == nil {
+		if err := w.wait(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	chunkSize := w.throttle.chunkSize()
+	written := 0
+	for len(p) > 0 {
+		if err := w.wait(); err != nil {
+			return written, err
+		}
+
+		n := min(len(p), chunkSize)
+		m, err := w.ResponseWriter.Write(p[:n])
+		written += m
+		if err != nil {
+			w.err = err
+			return written, err
+		}
+		rc.Flush()
+
+		w.owed = time.Duration(float64(w.throttle.delay(n)) * w.multiplier)
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// finish waits out the pause owed for the last chunk of a throttled body, so that the response takes
+// as long as its bandwidth allows even when it fits in a single chunk
+func (w *slowWriter) finish() {
+	if w.throttle != nil && w.err == nil {
+		w.wait()
+	}
+}
+
+// wait waits out the pause owed before writing more of the body
+func (w *slowWriter) wait() error {
+	err := sleepContext(w.ctx, w.owed)
+	w.owed = 0
+	if err != nil {
+		w.err = err
+		w.record.Outcome = OutcomeCancelled
+	}
+	return err
+}
+
+func (w *slowWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
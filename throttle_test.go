@@ -0,0 +1,246 @@
+package fauxmux
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThrottleConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ThrottleConfig
+		wantErr bool
+	}{
+		{name: "bandwidth", config: ThrottleConfig{BytesPerSecond: 1024}},
+		{name: "chunk delay", config: ThrottleConfig{ChunkSize: 1, ChunkDelay: time.Millisecond}},
+		{name: "nothing throttled", config: ThrottleConfig{ChunkSize: 1}, wantErr: true},
+		{name: "negative bandwidth", config: ThrottleConfig{BytesPerSecond: -1}, wantErr: true},
+		{name: "negative chunk size", config: ThrottleConfig{BytesPerSecond: 1, ChunkSize: -1}, wantErr: true},
+		{name: "negative chunk delay", config: ThrottleConfig{ChunkDelay: -time.Millisecond}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// newThrottledServer serves a body of size bytes throttled by throttle
+func newThrottledServer(t *testing.T, size int, throttle ThrottleConfig) (*Mux, *httptest.Server) {
+	t.Helper()
+	mux := NewMux()
+
+	err := RegisterEndpoint[string](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/download",
+		ResponseFormat: Bytes,
+		Throttle:       &throttle,
+		FakeDataFunc: func(v interface{}) error {
+			*v.(*string) = strings.Repeat("x", size)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	server := httptest.NewServer(mux.Mux())
+	t.Cleanup(server.Close)
+	return mux, server
+}
+
+func TestThrottle(t *testing.T) {
+	tests := []struct {
+		name         string
+		throttle     ThrottleConfig
+		wantLastByte time.Duration
+		wantDone     time.Duration
+	}{
+		// 4 chunks of 50 bytes, each followed by 50ms: the last one is sent after 150ms
+		{name: "bandwidth", throttle: ThrottleConfig{BytesPerSecond: 1000, ChunkSize: 50}, wantLastByte: 150 * time.Millisecond, wantDone: 200 * time.Millisecond},
+		// 4 chunks of 50 bytes, each followed by 40ms
+		{name: "chunk delay", throttle: ThrottleConfig{ChunkSize: 50, ChunkDelay: 40 * time.Millisecond}, wantLastByte: 120 * time.Millisecond, wantDone: 160 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux, server := newThrottledServer(t, 200, tt.throttle)
+
+			start := time.Now()
+			resp, err := http.Get(server.URL + "/download")
+			if err != nil {
+				t.Fatalf("failed to send request: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.ContentLength != 200 {
+				t.Errorf("expected content length 200 but got %d", resp.ContentLength)
+			}
+
+			first := make([]byte, 1)
+			if _, err := io.ReadFull(resp.Body, first); err != nil {
+				t.Fatalf("failed to read body: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed >= tt.wantLastByte {
+				t.Errorf("expected the first chunk to be flushed immediately but it took %v", elapsed)
+			}
+
+			rest, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read body: %v", err)
+			}
+			if len(rest)+1 != 200 {
+				t.Errorf("expected 200 bytes but got %d", len(rest)+1)
+			}
+			if elapsed := time.Since(start); elapsed < tt.wantLastByte {
+				t.Errorf("expected the body to take at least %v but it took %v", tt.wantLastByte, elapsed)
+			}
+
+			// the request is recorded once the handler has waited out the pause of the last chunk
+			deadline := time.Now().Add(time.Second)
+			for mux.Journal().Count("GET", "/download") == 0 {
+				if time.Now().After(deadline) {
+					t.Fatal("expected the request to be recorded")
+				}
+				time.Sleep(time.Millisecond)
+			}
+			if elapsed := time.Since(start); elapsed < tt.wantDone {
+				t.Errorf("expected the response to take at least %v but it took %v", tt.wantDone, elapsed)
+			}
+		})
+	}
+}
+
+func TestThrottle_SingleChunk(t *testing.T) {
+	// the default chunk of a tenth of the bandwidth, 100 bytes, holds the whole body
+	mux, _ := newThrottledServer(t, 50, ThrottleConfig{BytesPerSecond: 1000})
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/download", nil))
+	if w.Body.Len() != 50 {
+		t.Errorf("expected 50 bytes but got %d", w.Body.Len())
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected 50 bytes at 1000 bytes per second to take at least 50ms but it took %v", elapsed)
+	}
+}
+
+func TestThrottle_Cancelled(t *testing.T) {
+	mux, server := newThrottledServer(t, 100, ThrottleConfig{ChunkSize: 10, ChunkDelay: time.Second})
+
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	resp, err := client.Get(server.URL + "/download")
+	if err == nil {
+		_, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if err == nil {
+		t.Fatal("expected the client to time out")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for mux.Journal().Count("GET", "/download", WithOutcome(OutcomeCancelled)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the request to be recorded as cancelled")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestBodyLatency(t *testing.T) {
+	mux := NewMux()
+
+	err := RegisterEndpoint[string](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/slow-body",
+		ResponseFormat: Bytes,
+		BodyLatency:    FixedLatency(100 * time.Millisecond),
+		FakeDataFunc: func(v interface{}) error {
+			*v.(*string) = "hello"
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	server := httptest.NewServer(mux.Mux())
+	defer server.Close()
+
+	start := time.Now()
+	resp, err := http.Get(server.URL + "/slow-body")
+	if err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+	if elapsed := time.Since(start); elapsed >= 100*time.Millisecond {
+		t.Errorf("expected the headers to be sent immediately but they took %v", elapsed)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	if string(body) != "hello" {
+		t.Errorf("expected body %q but got %q", "hello", body)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected the body to take at least 100ms but it took %v", elapsed)
+	}
+
+	requests := mux.Journal().Requests()
+	if len(requests) != 1 || requests[0].BodyLatency != 100*time.Millisecond || requests[0].Outcome != OutcomeResponse {
+		t.Errorf("expected a response recorded with its body latency but got %+v", requests)
+	}
+}
+
+func TestBodyLatency_HeaderTimeout(t *testing.T) {
+	mux := NewMux()
+
+	err := RegisterEndpoint[string](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/slow-body",
+		ResponseFormat: Bytes,
+		BodyLatency:    FixedLatency(time.Second),
+		FakeDataFunc: func(v interface{}) error {
+			*v.(*string) = "hello"
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	server := httptest.NewServer(mux.Mux())
+	defer server.Close()
+
+	// the headers beat the response header timeout but the body doesn't beat the overall one
+	client := &http.Client{
+		Timeout:   50 * time.Millisecond,
+		Transport: &http.Transport{ResponseHeaderTimeout: 25 * time.Millisecond},
+	}
+	resp, err := client.Get(server.URL + "/slow-body")
+	if err != nil {
+		t.Fatalf("expected the headers before the response header timeout but got %v", err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadAll(resp.Body); err == nil {
+		t.Fatal("expected the client to time out reading the body")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for mux.Journal().Count("GET", "/slow-body", WithOutcome(OutcomeCancelled)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the request to be recorded as cancelled")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -2,26 +2,103 @@ package fauxmux
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net/http"
 	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
 var ErrInvalidResponseFormat = fmt.Errorf("invalid response format")
 
+// ErrEndpointNotFound is returned when no endpoint is registered for a method and path
+var ErrEndpointNotFound = fmt.Errorf("endpoint not found")
+
+// FieldError is a validation error attributed to a configuration field. Field is the
+// Go field path relative to the validated value, e.g. "ErrorResponseConfig.Responses[1].StatusCode".
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// fieldError attributes err to field, nesting the field path of err if it is already a FieldError
+func fieldError(field string, err error) error {
+	var fe *FieldError
+	if errors.As(err, &fe) {
+		if strings.HasPrefix(fe.Field, "[") {
+			return &FieldError{Field: field + fe.Field, Err: fe.Err}
+		}
+		return &FieldError{Field: field + "." + fe.Field, Err: fe.Err}
+	}
+	return &FieldError{Field: field, Err: err}
+}
+
 type ResponseFormat string
 
 const (
-	JSON  ResponseFormat = "json"
-	Bytes ResponseFormat = "bytes"
+	JSON        ResponseFormat = "json"
+	Bytes       ResponseFormat = "bytes"
+	XML         ResponseFormat = "xml"
+	YAML        ResponseFormat = "yaml"
+	MessagePack ResponseFormat = "msgpack"
+	CSV         ResponseFormat = "csv"
+	Text        ResponseFormat = "text"
 )
 
 type ErrorResponse struct {
-	StatusCode     int
-	Response       interface{}
-	ResponseFormat ResponseFormat
+	StatusCode int `json:"status_code"`
+	// Response is the body of the error response, encoded according to ResponseFormat;
+	// Bytes accepts a []byte or string
+	Response       interface{}    `json:"response"`
+	ResponseFormat ResponseFormat `json:"response_format"`
+	// ContentType overrides the content type implied by ResponseFormat
+	ContentType string `json:"content_type,omitempty"`
+	// Headers are added to the error response
+	Headers http.Header `json:"headers,omitempty"`
+	// Weight is the frequency of the error response relative to the other responses of its
+	// ErrorResponseConfig. Responses are picked uniformly when none has a weight; otherwise a
+	// response without one is never picked.
+	Weight float64 `json:"weight,omitempty"`
+}
+
+func (e ErrorResponse) Validate() error {
+	if e.StatusCode < 100 || e.StatusCode > 599 {
+		return fieldError("StatusCode", fmt.Errorf("invalid status code"))
+	}
+
+	if e.Response == nil {
+		return fieldError("Response", fmt.Errorf("response cannot be nil"))
+	}
+
+	if _, ok := lookupEncoder(e.ResponseFormat); !ok {
+		return fieldError("ResponseFormat", ErrInvalidResponseFormat)
+	}
+
+	if _, _, err := e.encode(); err != nil {
+		return fieldError("Response", err)
+	}
+
+	if e.Weight < 0 {
+		return fieldError("Weight", fmt.Errorf("weight cannot be negative"))
+	}
+
+	return nil
+}
+
+// encode returns the body of the error response and its default content type
+func (e ErrorResponse) encode() ([]byte, string, error) {
+	return encodeResponse(e.ResponseFormat, e.Response)
 }
 
 type ErrorResponseConfig struct {
@@ -31,28 +108,20 @@ type ErrorResponseConfig struct {
 
 func (e ErrorResponseConfig) Validate() error {
 	if e.Frequency < 0 {
-		return fmt.Errorf("frequency cannot be negative")
+		return fieldError("Frequency", fmt.Errorf("frequency cannot be negative"))
 	}
 
 	if e.Frequency > 1 {
-		return fmt.Errorf("frequency cannot be greater than 1")
+		return fieldError("Frequency", fmt.Errorf("frequency cannot be greater than 1"))
 	}
 
 	if len(e.Responses) == 0 {
-		return fmt.Errorf("responses cannot be empty")
+		return fieldError("Responses", fmt.Errorf("responses cannot be empty"))
 	}
 
-	for _, response := range e.Responses {
-		if response.StatusCode < 100 || response.StatusCode > 599 {
-			return fmt.Errorf("invalid status code")
-		}
-
-		if response.Response == nil {
-			return fmt.Errorf("response cannot be nil")
-		}
-
-		if !slices.Contains([]ResponseFormat{JSON, Bytes}, response.ResponseFormat) {
-			return ErrInvalidResponseFormat
+	for i, response := range e.Responses {
+		if err := response.Validate(); err != nil {
+			return fieldError(fmt.Sprintf("Responses[%d]", i), err)
 		}
 	}
 
@@ -66,124 +135,267 @@ type ListResponseConfig struct {
 
 func (l ListResponseConfig) Validate() error {
 	if l.MinItems < 0 {
-		return fmt.Errorf("min items cannot be negative")
+		return fieldError("MinItems", fmt.Errorf("min items cannot be negative"))
 	}
 
 	if l.MaxItems < 0 {
-		return fmt.Errorf("max items cannot be negative")
+		return fieldError("MaxItems", fmt.Errorf("max items cannot be negative"))
 	}
 
 	if l.MaxItems < l.MinItems {
-		return fmt.Errorf("max items cannot be less than min items")
+		return fieldError("MaxItems", fmt.Errorf("max items cannot be less than min items"))
 	}
 
 	return nil
 }
 
 type EndpointConfig struct {
-	Method              string
-	Path                string
-	MinLatency          time.Duration
-	MaxLatency          time.Duration
-	FakeDataFunc        FakeDataFunc
-	ResponseFormat      ResponseFormat
+	Method     string
+	Path       string
+	MinLatency time.Duration
+	MaxLatency time.Duration
+	// Latency, when set, is the latency distribution of the endpoint, replacing
+	// the uniform distribution between MinLatency and MaxLatency
+	Latency LatencyModel
+	// BodyLatency, when set, delays the body once the headers have been sent, whereas the latency
+	// set by Latency or MinLatency and MaxLatency delays the headers
+	BodyLatency    LatencyModel
+	FakeDataFunc   FakeDataFunc
+	ResponseFormat ResponseFormat
+	// ResponseFormats, when set, are the formats the endpoint can respond with, picked per request
+	// from the Accept header. ResponseFormat, if also set, is the one used when any is acceptable.
+	ResponseFormats     []ResponseFormat
 	ListResponseConfig  *ListResponseConfig
 	ErrorResponseConfig *ErrorResponseConfig
+	// FaultConfig, when set, answers a fraction of the requests with transport-level faults
+	FaultConfig *FaultConfig
+	// Throttle, when set, streams response bodies slowly instead of writing them at once
+	Throttle *ThrottleConfig
+	// Scenario, when set, makes the endpoint take part in a scenario shared with other endpoints
+	Scenario *ScenarioConfig
+	// Script, when set, answers requests with a sequence of scripted outcomes
+	Script *ScriptConfig
+	// RateLimit, when set, rejects the requests of clients exceeding a rate, in addition to the
+	// rate limit of the Mux
+	RateLimit *RateLimitConfig
+	// Seed, when set, gives the endpoint its own random source instead of sharing the Mux's
+	Seed *int64
+	// BindPathParams sets the response fields named like a wildcard of Path, e.g. the "id" field
+	// for "/users/{id}", to the value captured from the request path
+	BindPathParams bool
+	// ResponseHook, when set, is given the request and the faked response before it is written
+	ResponseHook ResponseHook
+	// Template, when set, is a text/template executed with TemplateData whose output is the body
+	// of successful responses, written with the content type of ResponseFormat instead of a faked T
+	Template string
+	// Fixture, when set, answers successful requests with a recorded payload instead of a faked T
+	Fixture *FixtureConfig
+	// Variants, when set, are the shapes of the successful responses, one picked per request
+	// according to their weights
+	Variants []ResponseVariant
 }
 
 func (e EndpointConfig) Validate() error {
 	if e.Method == "" {
-		return fmt.Errorf("method cannot be empty")
+		return fieldError("Method", fmt.Errorf("method cannot be empty"))
 	}
 
 	if e.Path == "" {
-		return fmt.Errorf("path cannot be empty")
+		return fieldError("Path", fmt.Errorf("path cannot be empty"))
+	}
+
+	if _, err := pathParamNames(e.Path); err != nil {
+		return fieldError("Path", err)
 	}
 
 	if e.MinLatency < 0 {
-		return fmt.Errorf("min latency cannot be negative")
+		return fieldError("MinLatency", fmt.Errorf("min latency cannot be negative"))
 	}
 
 	if e.MaxLatency < 0 {
-		return fmt.Errorf("max latency cannot be negative")
+		return fieldError("MaxLatency", fmt.Errorf("max latency cannot be negative"))
 	}
 
 	if e.MaxLatency < e.MinLatency {
-		return fmt.Errorf("max latency cannot be less than min latency")
+		return fieldError("MaxLatency", fmt.Errorf("max latency cannot be less than min latency"))
+	}
+
+	if e.Latency != nil {
+		if e.MinLatency != 0 || e.MaxLatency != 0 {
+			return fieldError("Latency", fmt.Errorf("latency cannot be combined with min and max latency"))
+		}
+
+		if err := e.Latency.Validate(); err != nil {
+			return fieldError("Latency", err)
+		}
+	}
+
+	if e.BodyLatency != nil {
+		if err := e.BodyLatency.Validate(); err != nil {
+			return fieldError("BodyLatency", err)
+		}
+	}
+
+	if len(e.ResponseFormats) == 0 || e.ResponseFormat != "" {
+		if _, ok := lookupEncoder(e.ResponseFormat); !ok {
+			return fieldError("ResponseFormat", ErrInvalidResponseFormat)
+		}
+	}
+
+	for i, format := range e.ResponseFormats {
+		if _, ok := lookupEncoder(format); !ok {
+			return fieldError(fmt.Sprintf("ResponseFormats[%d]", i), ErrInvalidResponseFormat)
+		}
 	}
 
-	if !slices.Contains([]ResponseFormat{JSON}, e.ResponseFormat) {
-		return fmt.Errorf("invalid response format")
+	if len(e.ResponseFormats) > 0 && e.ResponseFormat != "" && !slices.Contains(e.ResponseFormats, e.ResponseFormat) {
+		return fieldError("ResponseFormat", fmt.Errorf("response format must be one of the response formats"))
 	}
 
 	if e.ListResponseConfig != nil {
 		if err := e.ListResponseConfig.Validate(); err != nil {
-			return err
+			return fieldError("ListResponseConfig", err)
 		}
 	}
 
 	if e.ErrorResponseConfig != nil {
 		if err := e.ErrorResponseConfig.Validate(); err != nil {
-			return err
+			return fieldError("ErrorResponseConfig", err)
+		}
+	}
+
+	if e.FaultConfig != nil {
+		if err := e.FaultConfig.Validate(); err != nil {
+			return fieldError("FaultConfig", err)
+		}
+	}
+
+	if e.Throttle != nil {
+		if err := e.Throttle.Validate(); err != nil {
+			return fieldError("Throttle", err)
+		}
+	}
+
+	if e.Scenario != nil {
+		if err := e.Scenario.Validate(); err != nil {
+			return fieldError("Scenario", err)
+		}
+	}
+
+	if e.Script != nil {
+		if err := e.Script.Validate(); err != nil {
+			return fieldError("Script", err)
+		}
+	}
+
+	if e.RateLimit != nil {
+		if err := e.RateLimit.Validate(); err != nil {
+			return fieldError("RateLimit", err)
+		}
+	}
+
+	if e.Template != "" {
+		if e.ListResponseConfig != nil || len(e.ResponseFormats) > 0 || e.BindPathParams || e.ResponseHook != nil {
+			return fieldError("Template", fmt.Errorf("template cannot be combined with list, response formats, bind path params or response hook"))
+		}
+
+		if _, err := parseTemplate(e.Template, nil); err != nil {
+			return fieldError("Template", err)
+		}
+	}
+
+	if e.Fixture != nil {
+		if err := e.validateFixture(); err != nil {
+			return fieldError("Fixture", err)
+		}
+	}
+
+	if len(e.Variants) > 0 && (e.Template != "" || e.Fixture != nil) {
+		return fieldError("Variants", fmt.Errorf("variants cannot be combined with template or fixture"))
+	}
+
+	for i, variant := range e.Variants {
+		if err := variant.Validate(); err != nil {
+			return fieldError(fmt.Sprintf("Variants[%d]", i), err)
 		}
 	}
 
 	return nil
 }
 
-func getFakeDataFunc(endpointCfg EndpointConfig) FakeDataFunc {
+// fakeDataFunc returns the FakeDataFunc of the endpoint, else the one of the Mux, else the one set up
+// with Setup, else the built-in one drawing from rng
+func (fm *Mux) fakeDataFunc(endpointCfg EndpointConfig, rng *rand.Rand) FakeDataFunc {
 	if endpointCfg.FakeDataFunc != nil {
 		return endpointCfg.FakeDataFunc
 	}
-	return config.FakeDataFunc
+	if fm.fakeData != nil {
+		return fm.fakeData
+	}
+	if fakeDataFunc := setupFakeDataFunc(); fakeDataFunc != nil {
+		return fakeDataFunc
+	}
+	return newFakeDataFunc(rng)
 }
 
-func shouldTriggerError(errorCfg *ErrorResponseConfig) bool {
+func shouldTriggerError(rng *rand.Rand, errorCfg *ErrorResponseConfig) bool {
 	if errorCfg == nil {
 		return false
 	}
-	return rand.Float64() < errorCfg.Frequency
+	return rng.Float64() < errorCfg.Frequency
 }
 
-func handleErrorResponse(w http.ResponseWriter, endpointCfg EndpointConfig) {
+func handleErrorResponse(w http.ResponseWriter, rng *rand.Rand, endpointCfg EndpointConfig) *ErrorResponse {
 	if endpointCfg.ErrorResponseConfig == nil {
 		http.Error(w, "Internal Server Error: empty error config", http.StatusInternalServerError)
-		return
+		return nil
 	}
 
 	errorCfg := endpointCfg.ErrorResponseConfig
-	randErrorResponse := errorCfg.Responses[rand.Intn(len(errorCfg.Responses))]
-
-	w.WriteHeader(randErrorResponse.StatusCode)
+	randErrorResponse := errorCfg.Responses[weightedIndex(rng, len(errorCfg.Responses), func(i int) float64 {
+		return errorCfg.Responses[i].Weight
+	})]
+	writeErrorResponse(w, randErrorResponse)
+	return &randErrorResponse
+}
 
-	switch endpointCfg.ResponseFormat {
-	case Bytes:
-		w.Write(randErrorResponse.Response.([]byte))
-	case JSON:
-		writeJSON(w, randErrorResponse.Response)
-	default:
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+// writeErrorResponse writes errorResponse in its own format, regardless of the endpoint's
+func writeErrorResponse(w http.ResponseWriter, errorResponse ErrorResponse) {
+	body, contentType, err := errorResponse.encode()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal Server Error: %v", err), http.StatusInternalServerError)
 		return
 	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	for key, values := range errorResponse.Headers {
+		w.Header()[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+	if errorResponse.ContentType != "" {
+		w.Header().Set("Content-Type", errorResponse.ContentType)
+	}
+
+	w.WriteHeader(errorResponse.StatusCode)
+	w.Write(body)
 }
 
-func getResponseData[T any](endpointCfg EndpointConfig) (*T, error) {
+func getResponseData[T any](fakeDataFunc FakeDataFunc) (*T, error) {
 	var response T
-	err := getFakeDataFunc(endpointCfg)(&response)
+	err := fakeDataFunc(&response)
 	if err != nil {
 		return nil, err
 	}
 	return &response, nil
 }
 
-func getListResponseData[T any](endpointCfg EndpointConfig) ([]T, error) {
-	responseLen := endpointCfg.ListResponseConfig.MinItems
-	if endpointCfg.ListResponseConfig.MaxItems > endpointCfg.ListResponseConfig.MinItems {
-		responseLen = rand.Intn(endpointCfg.ListResponseConfig.MaxItems-endpointCfg.ListResponseConfig.MinItems+1) + endpointCfg.ListResponseConfig.MinItems
+func getListResponseData[T any](rng *rand.Rand, listCfg *ListResponseConfig, fakeDataFunc FakeDataFunc) ([]T, error) {
+	responseLen := listCfg.MinItems
+	if listCfg.MaxItems > listCfg.MinItems {
+		responseLen = rng.Intn(listCfg.MaxItems-listCfg.MinItems+1) + listCfg.MinItems
 	}
 	response := make([]T, 0, responseLen)
 
-	fakeDataFunc := getFakeDataFunc(endpointCfg)
 	for i := 0; i < responseLen; i++ {
 		var item T
 		err := fakeDataFunc(&item)
@@ -196,7 +408,26 @@ func getListResponseData[T any](endpointCfg EndpointConfig) ([]T, error) {
 	return response, nil
 }
 
+// writeResponse writes data encoded in format
+func writeResponse(w http.ResponseWriter, statusCode int, format ResponseFormat, data interface{}) {
+	body, contentType, err := encodeResponse(format, data)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Internal Server Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(statusCode)
+	w.Write(body)
+}
+
 func writeJSON(w http.ResponseWriter, data interface{}) {
+	writeJSONStatus(w, http.StatusOK, data)
+}
+
+func writeJSONStatus(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(data)
 }
@@ -1,8 +1,9 @@
 package fauxmux
 
 import (
-	"encoding/json"
+	"errors"
 	"io"
+	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"testing"
@@ -71,7 +72,27 @@ func TestErrorResponseConfig_Validate(t *testing.T) {
 			config: ErrorResponseConfig{
 				Frequency: 0.5,
 				Responses: []ErrorResponse{
-					{StatusCode: 200, Response: "OK", ResponseFormat: "xml"},
+					{StatusCode: 200, Response: "OK", ResponseFormat: "toml"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "string bytes response",
+			config: ErrorResponseConfig{
+				Frequency: 0.5,
+				Responses: []ErrorResponse{
+					{StatusCode: 500, Response: "Internal Server Error", ResponseFormat: Bytes},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid bytes response",
+			config: ErrorResponseConfig{
+				Frequency: 0.5,
+				Responses: []ErrorResponse{
+					{StatusCode: 500, Response: Error{Message: "Internal"}, ResponseFormat: Bytes},
 				},
 			},
 			wantErr: true,
@@ -213,7 +234,7 @@ func TestEndpointConfig_Validate(t *testing.T) {
 				Path:           "/test",
 				MinLatency:     10 * time.Millisecond,
 				MaxLatency:     100 * time.Millisecond,
-				ResponseFormat: "xml",
+				ResponseFormat: "toml",
 			},
 			wantErr: true,
 		},
@@ -230,13 +251,15 @@ func TestEndpointConfig_Validate(t *testing.T) {
 
 func TestHandleErrorResponse(t *testing.T) {
 	tests := []struct {
-		name        string
-		endpointCfg EndpointConfig
-		wantStatus  int
-		wantBody    string
+		name            string
+		endpointCfg     EndpointConfig
+		wantStatus      int
+		wantBody        string
+		wantContentType string
+		wantHeaders     http.Header
 	}{
 		{
-			name: "valid error response",
+			name: "json error on json endpoint",
 			endpointCfg: EndpointConfig{
 				ResponseFormat: JSON,
 				ErrorResponseConfig: &ErrorResponseConfig{
@@ -245,23 +268,121 @@ func TestHandleErrorResponse(t *testing.T) {
 					},
 				},
 			},
-			wantStatus: 500,
-			wantBody:   `{"error":"internal server error"}`,
+			wantStatus:      500,
+			wantBody:        `{"error":"internal server error"}` + "\n",
+			wantContentType: "application/json",
+		},
+		{
+			name: "json string error",
+			endpointCfg: EndpointConfig{
+				ResponseFormat: JSON,
+				ErrorResponseConfig: &ErrorResponseConfig{
+					Responses: []ErrorResponse{
+						{StatusCode: 400, Response: "bad request", ResponseFormat: JSON},
+					},
+				},
+			},
+			wantStatus:      400,
+			wantBody:        `"bad request"` + "\n",
+			wantContentType: "application/json",
+		},
+		{
+			name: "bytes error on json endpoint",
+			endpointCfg: EndpointConfig{
+				ResponseFormat: JSON,
+				ErrorResponseConfig: &ErrorResponseConfig{
+					Responses: []ErrorResponse{
+						{StatusCode: 502, Response: []byte{0xde, 0xad}, ResponseFormat: Bytes},
+					},
+				},
+			},
+			wantStatus:      502,
+			wantBody:        "\xde\xad",
+			wantContentType: "application/octet-stream",
+		},
+		{
+			name: "string bytes error",
+			endpointCfg: EndpointConfig{
+				ResponseFormat: JSON,
+				ErrorResponseConfig: &ErrorResponseConfig{
+					Responses: []ErrorResponse{
+						{StatusCode: 503, Response: "Service Unavailable", ResponseFormat: Bytes},
+					},
+				},
+			},
+			wantStatus:      503,
+			wantBody:        "Service Unavailable",
+			wantContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name: "content type and headers",
+			endpointCfg: EndpointConfig{
+				ResponseFormat: JSON,
+				ErrorResponseConfig: &ErrorResponseConfig{
+					Responses: []ErrorResponse{
+						{
+							StatusCode:     429,
+							Response:       "<error>slow down</error>",
+							ResponseFormat: Bytes,
+							ContentType:    "application/xml",
+							Headers:        http.Header{"Retry-After": {"30"}, "x-request-id": {"abc"}},
+						},
+					},
+				},
+			},
+			wantStatus:      429,
+			wantBody:        "<error>slow down</error>",
+			wantContentType: "application/xml",
+			wantHeaders:     http.Header{"Retry-After": {"30"}, "X-Request-Id": {"abc"}},
+		},
+		{
+			name: "content type from headers",
+			endpointCfg: EndpointConfig{
+				ResponseFormat: JSON,
+				ErrorResponseConfig: &ErrorResponseConfig{
+					Responses: []ErrorResponse{
+						{
+							StatusCode:     500,
+							Response:       map[string]string{"type": "about:blank"},
+							ResponseFormat: JSON,
+							Headers:        http.Header{"Content-Type": {"application/problem+json"}},
+						},
+					},
+				},
+			},
+			wantStatus:      500,
+			wantBody:        `{"type":"about:blank"}` + "\n",
+			wantContentType: "application/problem+json",
+		},
+		{
+			name: "unencodable response",
+			endpointCfg: EndpointConfig{
+				ResponseFormat: JSON,
+				ErrorResponseConfig: &ErrorResponseConfig{
+					Responses: []ErrorResponse{
+						{StatusCode: 404, Response: 42, ResponseFormat: Bytes},
+					},
+				},
+			},
+			wantStatus:      500,
+			wantBody:        "Internal Server Error: bytes response must be a []byte or string, got int\n",
+			wantContentType: "text/plain; charset=utf-8",
 		},
 		{
 			name: "empty error config",
 			endpointCfg: EndpointConfig{
 				ResponseFormat: JSON,
 			},
-			wantStatus: 500,
-			wantBody:   "Internal Server Error: empty error config\n",
+			wantStatus:      500,
+			wantBody:        "Internal Server Error: empty error config\n",
+			wantContentType: "text/plain; charset=utf-8",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			w := httptest.NewRecorder()
-			handleErrorResponse(w, tt.endpointCfg)
+			handleErrorResponse(w, newRand(1), tt.endpointCfg)
 
 			resp := w.Result()
 			body, _ := io.ReadAll(resp.Body)
@@ -270,19 +391,46 @@ func TestHandleErrorResponse(t *testing.T) {
 				t.Errorf("handleErrorResponse() status = %v, want %v", resp.StatusCode, tt.wantStatus)
 			}
 
-			if tt.endpointCfg.ResponseFormat == JSON {
-				var gotBody map[string]string
-				json.Unmarshal(body, &gotBody)
-				var wantBody map[string]string
-				json.Unmarshal([]byte(tt.wantBody), &wantBody)
-				if !reflect.DeepEqual(gotBody, wantBody) {
-					t.Errorf("handleErrorResponse() body = %v, want %v", gotBody, wantBody)
-				}
-			} else {
-				if string(body) != tt.wantBody {
-					t.Errorf("handleErrorResponse() body = %v, want %v", string(body), tt.wantBody)
+			if string(body) != tt.wantBody {
+				t.Errorf("handleErrorResponse() body = %q, want %q", string(body), tt.wantBody)
+			}
+
+			if contentType := resp.Header.Get("Content-Type"); contentType != tt.wantContentType {
+				t.Errorf("handleErrorResponse() content type = %v, want %v", contentType, tt.wantContentType)
+			}
+
+			for key, values := range tt.wantHeaders {
+				if got := resp.Header.Values(key); !reflect.DeepEqual(got, values) {
+					t.Errorf("handleErrorResponse() header %s = %v, want %v", key, got, values)
 				}
 			}
 		})
 	}
 }
+
+func TestEndpointConfig_ValidateFieldError(t *testing.T) {
+	config := EndpointConfig{
+		Method:         "GET",
+		Path:           "/test",
+		ResponseFormat: JSON,
+		ErrorResponseConfig: &ErrorResponseConfig{
+			Frequency: 0.5,
+			Responses: []ErrorResponse{
+				{StatusCode: 500, Response: "Internal", ResponseFormat: JSON},
+				{StatusCode: 500, Response: "Internal", ResponseFormat: "toml"},
+			},
+		},
+	}
+
+	err := config.Validate()
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) {
+		t.Fatalf("EndpointConfig.Validate() error = %v, want FieldError", err)
+	}
+	if want := "ErrorResponseConfig.Responses[1].ResponseFormat"; fieldErr.Field != want {
+		t.Errorf("EndpointConfig.Validate() field = %v, want %v", fieldErr.Field, want)
+	}
+	if !errors.Is(err, ErrInvalidResponseFormat) {
+		t.Errorf("EndpointConfig.Validate() error = %v, want %v", err, ErrInvalidResponseFormat)
+	}
+}
@@ -0,0 +1,75 @@
+package fauxmux
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// ResponseVariant is one of the shapes of the successful responses of an endpoint, e.g. a full
+// object, one whose optional fields are empty or an unusual but valid one. A variant setting neither
+// Response nor Hook is the faked response as is.
+type ResponseVariant struct {
+	// Name identifies the variant in the journal, see RecordedRequest.Variant
+	Name string
+	// Weight is the frequency of the variant relative to the others. Variants are picked uniformly
+	// when none has a weight; otherwise a variant without one is never picked.
+	Weight float64
+	// Response, when set, is written as is instead of a faked response: it is shared by every
+	// response, so neither BindPathParams nor the endpoint's ResponseHook apply to it
+	Response any
+	// Hook, when set, changes the faked response like a ResponseHook, before the endpoint's own
+	Hook ResponseHook
+}
+
+func (v ResponseVariant) Validate() error {
+	if v.Weight < 0 {
+		return fieldError("Weight", fmt.Errorf("weight cannot be negative"))
+	}
+
+	if v.Response != nil && v.Hook != nil {
+		return fieldError("Hook", fmt.Errorf("response and hook cannot both be set"))
+	}
+
+	return nil
+}
+
+// weightedIndex picks one of n items with the probability of its weight relative to the others.
+// Items are picked uniformly when none has a weight; otherwise an item without one, whose weight
+// returns 0, is never picked, so that a weight of 0 disables an item.
+func weightedIndex(rng *rand.Rand, n int, weight func(i int) float64) int {
+	total := 0.0
+	last := 0
+	for i := 0; i < n; i++ {
+		if w := weight(i); w > 0 {
+			total += w
+			last = i
+		}
+	}
+	if total == 0 {
+		return rng.Intn(n)
+	}
+
+	pick := rng.Float64() * total
+	for i := 0; i < n; i++ {
+		w := weight(i)
+		if w <= 0 {
+			continue
+		}
+		if pick < w {
+			return i
+		}
+		pick -= w
+	}
+	// rounding errors may leave a sliver of the total past the last weighted item
+	return last
+}
+
+// pickVariant returns the variant of the response to a request, or nil if the endpoint has none
+func (e *endpoint) pickVariant() *ResponseVariant {
+	if len(e.cfg.Variants) == 0 {
+		return nil
+	}
+	return &e.cfg.Variants[weightedIndex(e.rng, len(e.cfg.Variants), func(i int) float64 {
+		return e.cfg.Variants[i].Weight
+	})]
+}
@@ -0,0 +1,185 @@
+package fauxmux
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWeightedIndex(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []float64
+		want    []int
+	}{
+		{name: "weighted", weights: []float64{8, 1, 1}, want: []int{8000, 1000, 1000}},
+		{name: "zero weight disables", weights: []float64{8, 0, 2}, want: []int{8000, 0, 2000}},
+		{name: "fractional weights with one unset", weights: []float64{0.8, 0.2, 0}, want: []int{8000, 2000, 0}},
+		{name: "no weights", weights: []float64{0, 0}, want: []int{5000, 5000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := newRand(1)
+			counts := make([]int, len(tt.weights))
+			for i := 0; i < 10000; i++ {
+				counts[weightedIndex(rng, len(tt.weights), func(i int) float64 { return tt.weights[i] })]++
+			}
+
+			for i, want := range tt.want {
+				if counts[i] < want*9/10 || counts[i] > want*11/10 {
+					t.Errorf("expected item %d to be picked about %d times but got %d", i, want, counts[i])
+				}
+			}
+		})
+	}
+}
+
+func TestResponseVariants(t *testing.T) {
+	mux := NewMux(WithSeed(1))
+	err := RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/users/1",
+		ResponseFormat: JSON,
+		Variants: []ResponseVariant{
+			{Name: "full", Weight: 80},
+			{Name: "sparse", Weight: 15, Hook: TypedResponseHook(func(r *Request, user *User) error {
+				user.Email = ""
+				return nil
+			})},
+			{Name: "legacy", Weight: 5, Response: map[string]any{"user_id": 1}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	bodies := map[string]string{}
+	for i := 0; i < 1000; i++ {
+		w := httptest.NewRecorder()
+		mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+		requests := mux.Journal().Requests()
+		bodies[requests[len(requests)-1].Variant] = w.Body.String()
+	}
+
+	if !strings.Contains(bodies["full"], "doe@testing.com") {
+		t.Errorf("expected the full variant to be faked but got %q", bodies["full"])
+	}
+	if !strings.Contains(bodies["sparse"], `"email":""`) {
+		t.Errorf("expected the sparse variant to have no email but got %q", bodies["sparse"])
+	}
+	if bodies["legacy"] != "{\"user_id\":1}\n" {
+		t.Errorf("expected the legacy variant to be fixed but got %q", bodies["legacy"])
+	}
+
+	journal := mux.Journal()
+	for name, want := range map[string]int{"full": 800, "sparse": 150, "legacy": 50} {
+		if got := journal.Count("GET", "/users/1", WithVariant(name)); got < want*7/10 || got > want*13/10 {
+			t.Errorf("expected variant %s about %d times but got %d", name, want, got)
+		}
+	}
+}
+
+const testVariantsConfig = `endpoints:
+  - method: GET
+    path: /orders/1
+    response_format: json
+    response: {id: 1, status: paid}
+    variants:
+      - {name: usual, weight: 3}
+      - {name: refunded, weight: 1, response: {id: 1, status: refunded, refund: {amount: 10}}}
+    errors:
+      frequency: 0.5
+      responses:
+        - {status_code: 500, response_format: text, response: oops, weight: 9}
+        - {status_code: 503, response_format: text, response: down, weight: 1}
+`
+
+func TestResponseVariants_Config(t *testing.T) {
+	mux := NewMux(WithSeed(1))
+	if err := LoadConfig(mux, strings.NewReader(testVariantsConfig)); err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	for i := 0; i < 400; i++ {
+		mux.Mux().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/1", nil))
+	}
+
+	journal := mux.Journal()
+	usual, refunded := journal.Count("GET", "/orders/1", WithVariant("usual")), journal.Count("GET", "/orders/1", WithVariant("refunded"))
+	if usual < 2*refunded || refunded == 0 {
+		t.Errorf("expected about 3 usual responses per refunded one but got %d and %d", usual, refunded)
+	}
+	internal, unavailable := journal.Count("GET", "/orders/1", WithStatusCode(500)), journal.Count("GET", "/orders/1", WithStatusCode(503))
+	if internal < 5*unavailable || unavailable == 0 {
+		t.Errorf("expected about 9 internal errors per unavailable one but got %d and %d", internal, unavailable)
+	}
+
+	err := LoadConfig(NewMux(), strings.NewReader(strings.Replace(testVariantsConfig, "weight: 1,", "weight: -1,", 1)))
+	if err == nil || !strings.Contains(err.Error(), "endpoints[0].variants[1].weight") {
+		t.Errorf("expected an error at the negative weight but got %v", err)
+	}
+}
+
+func TestResponseVariants_StaticResponseNotModified(t *testing.T) {
+	mux := NewMux()
+	err := RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/users/{id}",
+		ResponseFormat: JSON,
+		BindPathParams: true,
+		Variants:       []ResponseVariant{{Name: "shared", Response: &User{ID: 1, Name: "shared"}}},
+		ResponseHook: TypedResponseHook(func(r *Request, user *User) error {
+			user.Name += "!"
+			return nil
+		}),
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			mux.Mux().ServeHTTP(w, httptest.NewRequest("GET", "/users/2", nil))
+			if w.Body.String() != "{\"id\":1,\"name\":\"shared\",\"email\":\"\"}\n" {
+				t.Errorf("expected the static response as is but got %q", w.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestResponseVariants_Disabled(t *testing.T) {
+	mux := NewMux(WithSeed(1))
+	err := RegisterEndpoint[User](mux, EndpointConfig{
+		Method:         "GET",
+		Path:           "/users/1",
+		ResponseFormat: JSON,
+		Variants: []ResponseVariant{
+			{Name: "usual", Weight: 0.8},
+			{Name: "rare", Weight: 0.2},
+			{Name: "unweighted"},
+			{Name: "disabled", Weight: 0},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to register endpoint: %v", err)
+	}
+
+	for i := 0; i < 200; i++ {
+		mux.Mux().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/1", nil))
+	}
+
+	journal := mux.Journal()
+	for _, name := range []string{"unweighted", "disabled"} {
+		journal.AssertNotCalled(t, "GET", "/users/1", WithVariant(name))
+	}
+	if rare := journal.Count("GET", "/users/1", WithVariant("rare")); rare == 0 || rare > 80 {
+		t.Errorf("expected the rare variant about 40 times but got %d", rare)
+	}
+}